@@ -12,7 +12,7 @@ import (
 
 func main() {
 	m := src.InitialModel()
-	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithOutput(src.TermOutput))
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGTSTP)
 	go func() {
@@ -0,0 +1,112 @@
+package src
+
+import (
+	"archive/tar"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ─── Archive preview ──────────────────────────────────────────────────────────
+
+// archiveEntry aggregates everything below one top-level name.
+type archiveEntry struct {
+	name  string
+	isDir bool
+	size  int64
+	files int
+}
+
+// archiveSummary lists the top-level entries of a local zip/tar archive
+// without mounting it.
+func archiveSummary(path string, archiveSize int64) (string, error) {
+	top := make(map[string]*archiveEntry)
+	var total, packed int64
+	count := 0
+	kind := ""
+
+	add := func(name string, size int64, dir bool) {
+		name = strings.TrimPrefix(name, "./")
+		if name == "" || name == "." {
+			return
+		}
+		first, rest, nested := strings.Cut(strings.TrimSuffix(name, "/"), "/")
+		e := top[first]
+		if e == nil {
+			e = &archiveEntry{name: first}
+			top[first] = e
+		}
+		if nested || dir {
+			e.isDir = true
+		}
+		if !dir {
+			e.size += size
+			total += size
+			count++
+			if nested && rest != "" {
+				e.files++
+			}
+		}
+	}
+
+	if filepath.Ext(path) == ".zip" {
+		z, err := newZipVFS(path)
+		if err != nil {
+			return "", err
+		}
+		defer z.Close()
+		kind = "ZIP"
+		for _, f := range z.reader.File {
+			add(f.Name, int64(f.UncompressedSize64), f.FileInfo().IsDir())
+			packed += int64(f.CompressedSize64)
+		}
+	} else {
+		t, err := newTarVFS(path)
+		if err != nil {
+			return "", err
+		}
+		kind = "TAR"
+		if t.isGz {
+			kind = "TAR.GZ"
+		}
+		for name, hdr := range t.entries {
+			add(name, hdr.Size, hdr.Typeflag == tar.TypeDir)
+		}
+		packed = archiveSize
+	}
+
+	entries := make([]*archiveEntry, 0, len(top))
+	for _, e := range top {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].isDir != entries[j].isDir {
+			return entries[i].isDir
+		}
+		return strings.ToLower(entries[i].name) < strings.ToLower(entries[j].name)
+	})
+
+	ratio := "–"
+	if total > 0 {
+		ratio = fmt.Sprintf("%.1f%%", float64(packed)*100/float64(total))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📦 %s archive\n\n", kind)
+	fmt.Fprintf(&sb, "%d files · %d top-level entries\n", count, len(entries))
+	fmt.Fprintf(&sb, "Uncompressed: %s · Archive: %s · Ratio: %s\n\n", humanSize(total), humanSize(archiveSize), ratio)
+	for i, e := range entries {
+		if i >= previewLines {
+			fmt.Fprintf(&sb, "… and %d more\n", len(entries)-i)
+			break
+		}
+		name := fileStyle.Render(e.name)
+		extra := ""
+		if e.isDir {
+			name = dirStyle.Render(e.name + "/")
+			extra = fmt.Sprintf("  (%d files)", e.files)
+		}
+		fmt.Fprintf(&sb, "  %s  %s%s\n", name, humanSize(e.size), extra)
+	}
+	return sb.String(), nil
+}
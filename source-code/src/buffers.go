@@ -0,0 +1,221 @@
+package src
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorBuffer is a single file opened in the built-in editor.
+// Each buffer keeps its own textarea so cursor and scroll survive switching.
+type editorBuffer struct {
+	file     string
+	vfs      vfsHandler
+	editor   textarea.Model
+	original string
+	dirty    bool
+
+	// on-disk encoding and line endings, reapplied on save
+	format      textFormat
+	savedFormat textFormat
+}
+
+func (b *editorBuffer) name() string {
+	name := filepath.Base(b.file)
+	if b.dirty {
+		name += " ●"
+	}
+	return name
+}
+
+func (b *editorBuffer) updateDirty() {
+	b.dirty = b.editor.Value() != b.original || b.format != b.savedFormat
+}
+
+// ─── Buffer management ────────────────────────────────────────────────────────
+
+// findBuffer returns the index of the buffer holding file on vfs, or -1.
+func (m *Model) findBuffer(vfs vfsHandler, file string) int {
+	for i := range m.buffers {
+		if m.buffers[i].file == file && m.buffers[i].vfs.VFSName() == vfs.VFSName() {
+			return i
+		}
+	}
+	return -1
+}
+
+// addBuffer opens a new buffer with content and makes it the active one.
+func (m *Model) addBuffer(vfs vfsHandler, file, content string, format textFormat) {
+	ta := textarea.New()
+	ta.Placeholder = "Edit your file here…"
+	ta.CharLimit = 0
+	ta.MaxHeight = 0
+	w, h := m.editorSize()
+	ta.SetWidth(w)
+	ta.SetHeight(h)
+	ta.SetValue(content)
+	m.buffers = append(m.buffers, editorBuffer{
+		file:     file,
+		vfs:      vfs,
+		editor:   ta,
+		original: content,
+
+		format:      format,
+		savedFormat: format,
+	})
+	m.switchBuffer(len(m.buffers) - 1)
+}
+
+// switchBuffer focuses buffer i and enters editor mode.
+func (m *Model) switchBuffer(i int) {
+	if i < 0 || i >= len(m.buffers) {
+		return
+	}
+	if m.activeBuffer < len(m.buffers) {
+		m.buffers[m.activeBuffer].editor.Blur()
+	}
+	m.activeBuffer = i
+	m.buffers[i].editor.Focus()
+	m.mode = editorMode
+}
+
+// gotoLine puts the cursor at the start of line n (0-based) and scrolls to it.
+func (b *editorBuffer) gotoLine(n int) {
+	for b.editor.Line() > n {
+		b.editor.CursorUp()
+	}
+	// Wrapped lines take several steps; every step passes at least one rune.
+	for steps := b.editor.Length(); b.editor.Line() < n && steps > 0; steps-- {
+		b.editor.CursorDown()
+	}
+	b.editor.CursorStart()
+	b.editor, _ = b.editor.Update(nil)
+}
+
+func (m *Model) cycleBuffer(delta int) {
+	if len(m.buffers) < 2 {
+		return
+	}
+	m.switchBuffer((m.activeBuffer + delta + len(m.buffers)) % len(m.buffers))
+}
+
+func (m *Model) currentBuffer() *editorBuffer {
+	if m.activeBuffer >= len(m.buffers) {
+		return nil
+	}
+	return &m.buffers[m.activeBuffer]
+}
+
+// saveBuffer writes buffer i back through the VFS it was opened from.
+func (m *Model) saveBuffer(i int) error {
+	b := &m.buffers[i]
+	content := b.editor.Value()
+	data, err := encodeText(content, b.format)
+	if err != nil {
+		return err
+	}
+	w, err := b.vfs.Create(b.file)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	b.original = content
+	b.savedFormat = b.format
+	b.dirty = false
+	return nil
+}
+
+// closeBuffer drops buffer i; leaves editor mode when none are left.
+func (m *Model) closeBuffer(i int) {
+	if i < 0 || i >= len(m.buffers) {
+		return
+	}
+	m.buffers = append(m.buffers[:i], m.buffers[i+1:]...)
+	if len(m.buffers) == 0 {
+		m.activeBuffer = 0
+		m.mode = explorerMode
+		m.commandInput.Focus()
+		return
+	}
+	if m.activeBuffer >= len(m.buffers) {
+		m.activeBuffer = len(m.buffers) - 1
+	}
+	if m.bufferCursor >= len(m.buffers) {
+		m.bufferCursor = len(m.buffers) - 1
+	}
+	if m.mode == editorMode {
+		m.switchBuffer(m.activeBuffer)
+	}
+}
+
+// promptCloseBuffer closes the active buffer, asking first if it is dirty.
+func (m *Model) promptCloseBuffer() {
+	b := m.currentBuffer()
+	if b == nil {
+		return
+	}
+	if !b.dirty {
+		m.closeBuffer(m.activeBuffer)
+		return
+	}
+	idx := m.activeBuffer
+	m.askConfirm(fmt.Sprintf("'%s' has unsaved changes. Close anyway? (y/n)", filepath.Base(b.file)),
+		     func(m *Model) tea.Cmd {
+			     m.closeBuffer(idx)
+			     return nil
+		     })
+}
+
+func (m *Model) dirtyBuffers() int {
+	n := 0
+	for i := range m.buffers {
+		if m.buffers[i].dirty {
+			n++
+		}
+	}
+	return n
+}
+
+// promptQuit quits immediately unless some buffer has unsaved changes.
+func (m *Model) promptQuit() tea.Cmd {
+	dirty := m.dirtyBuffers()
+	if dirty == 0 {
+		m.quitting = true
+		return tea.Quit
+	}
+	m.askConfirm(fmt.Sprintf("%d buffer(s) have unsaved changes. Quit anyway? (y/n)", dirty),
+		     func(m *Model) tea.Cmd {
+			     m.quitting = true
+			     return tea.Quit
+		     })
+	return nil
+}
+
+func (m *Model) openBufferList() {
+	if len(m.buffers) == 0 {
+		m.statusMsg = warnStyle.Render("No open buffers")
+		return
+	}
+	m.bufferCursor = m.activeBuffer
+	m.mode = bufferListMode
+}
+
+// editorSize returns the textarea dimensions; one row is left for the tabs.
+func (m *Model) editorSize() (int, int) {
+	if m.termW == 0 || m.termH == 0 {
+		return 76, 20
+	}
+	h := m.termH - 7
+	if h < 5 {
+		h = 5
+	}
+	return m.termW - 4, h
+}
@@ -0,0 +1,315 @@
+package src
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/sync/errgroup"
+)
+
+// ─── Directory compare ────────────────────────────────────────────────────────
+
+// compareMTimeSlack absorbs timestamp rounding between backends (sftp and
+// tar keep whole seconds, FAT two).
+const compareMTimeSlack = 2 * time.Second
+
+// Compare marks shown after the name; the selected ones are what F5 should
+// copy to the other side.
+const (
+	markOnly    = "only here"
+	markNewer   = "newer"
+	markOlder   = "older"
+	markLarger  = "larger"
+	markSmaller = "smaller"
+	markDiffers = "differs"
+	markInside  = "differs inside" // a subdirectory; F5 does not copy these
+	markSkipped = "not compared"   // a subdirectory too large or unreadable
+)
+
+// compareSide is one panel's directory as seen by a compare job.
+type compareSide struct {
+	vfs vfsHandler
+	dir string
+}
+
+// compareMsg delivers the marks for both panels.
+type compareMsg struct {
+	gen   int
+	sides [2]compareSide
+	marks [2]map[string]string
+	err   error
+}
+
+// compareMark is the compare mark of name in the panel's current directory.
+func (p *panel) compareMark(name string) string {
+	if p.compareDir != p.currentDir {
+		return ""
+	}
+	return p.compareMarks[name]
+}
+
+// startCompare compares the directories of both panels in the background;
+// byChecksum also hashes files whose size and mtime agree.
+func (m *Model) startCompare(byChecksum bool) tea.Cmd {
+	m.compareGen++
+	gen := m.compareGen
+	var sides [2]compareSide
+	for i := range m.panels {
+		sides[i] = compareSide{m.panels[i].vfs, m.panels[i].currentDir}
+	}
+	how := "size and date"
+	if byChecksum {
+		how = "checksum"
+	}
+	m.statusMsg = warnStyle.Render(fmt.Sprintf("Comparing by %s…", how))
+	return func() tea.Msg {
+		marks, err := compareDirs(sides, byChecksum)
+		return compareMsg{gen: gen, sides: sides, marks: marks, err: err}
+	}
+}
+
+func compareDirs(sides [2]compareSide, byChecksum bool) ([2]map[string]string, error) {
+	var marks [2]map[string]string
+	var infos [2]map[string]fs.FileInfo
+	for i, s := range sides {
+		entries, err := s.vfs.ReadDir(s.dir)
+		if err != nil {
+			return marks, err
+		}
+		marks[i] = make(map[string]string)
+		infos[i] = make(map[string]fs.FileInfo, len(entries))
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil {
+				infos[i][e.Name()] = info
+			}
+		}
+	}
+
+	var same []string    // files whose size and date agree
+	var subdirs []string // directories on both sides
+	for i := range sides {
+		for name, a := range infos[i] {
+			b, ok := infos[1-i][name]
+			if !ok {
+				marks[i][name] = markOnly
+				continue
+			}
+			if i == 1 {
+				continue
+			}
+			if a.IsDir() && b.IsDir() {
+				subdirs = append(subdirs, name)
+				continue
+			}
+			if a.IsDir() || b.IsDir() {
+				continue
+			}
+			switch dt := a.ModTime().Sub(b.ModTime()); {
+				case dt > compareMTimeSlack:
+					marks[0][name], marks[1][name] = markNewer, markOlder
+				case dt < -compareMTimeSlack:
+					marks[0][name], marks[1][name] = markOlder, markNewer
+				case a.Size() > b.Size():
+					marks[0][name], marks[1][name] = markLarger, markSmaller
+				case a.Size() < b.Size():
+					marks[0][name], marks[1][name] = markSmaller, markLarger
+				default:
+					same = append(same, name)
+			}
+		}
+	}
+	// Subdirectories are walked (up to dirStatsMax entries each) so that a
+	// difference deep inside still shows on the top-level directory.
+	for _, name := range subdirs {
+		var sub [2]compareSide
+		for i, s := range sides {
+			sub[i] = compareSide{s.vfs, filepath.Join(s.dir, name)}
+		}
+		differs, err := treesDiffer(sub, byChecksum)
+		switch {
+			case err != nil:
+				marks[0][name], marks[1][name] = markSkipped, markSkipped
+			case differs:
+				marks[0][name], marks[1][name] = markInside, markInside
+		}
+	}
+	if !byChecksum {
+		return marks, nil
+	}
+
+	// Content check for the rest; newer/larger pairs that turn out to be
+	// identical lose their marks as well.
+	var check []string
+	for name, mark := range marks[0] {
+		if mark != markOnly && !infos[0][name].IsDir() && !infos[1][name].IsDir() {
+			check = append(check, name)
+		}
+	}
+	check = append(check, same...)
+	differs := make([]bool, len(check))
+	eg := errgroup.Group{}
+	eg.SetLimit(4)
+	for i, name := range check {
+		i, name := i, name
+		eg.Go(func() error {
+			a, err := vfsChecksum(sides[0].vfs, filepath.Join(sides[0].dir, name))
+			if err != nil {
+				return err
+			}
+			b, err := vfsChecksum(sides[1].vfs, filepath.Join(sides[1].dir, name))
+			if err != nil {
+				return err
+			}
+			differs[i] = a != b
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return marks, err
+	}
+	for i, name := range check {
+		switch {
+			case !differs[i]:
+				delete(marks[0], name)
+				delete(marks[1], name)
+			case marks[0][name] == "":
+				marks[0][name], marks[1][name] = markDiffers, markDiffers
+		}
+	}
+	return marks, nil
+}
+
+// treesDiffer reports whether two directory trees hold different paths or
+// files, by size and date or by checksum.
+func treesDiffer(sides [2]compareSide, byChecksum bool) (bool, error) {
+	var trees [2]syncTree
+	for i, s := range sides {
+		t, err := scanSyncTree(s.vfs, s.dir)
+		if err != nil {
+			return false, err
+		}
+		trees[i] = t
+	}
+	if len(trees[0]) != len(trees[1]) {
+		return true, nil
+	}
+	for rel, a := range trees[0] {
+		b, ok := trees[1][rel]
+		switch {
+			case !ok || a.IsDir != b.IsDir || a.Size != b.Size:
+				return true, nil
+			case a.IsDir:
+				continue
+			case !byChecksum:
+				if !sameFile(a, b) {
+					return true, nil
+				}
+				continue
+		}
+		var sums [2][32]byte
+		for i, s := range sides {
+			sum, err := vfsChecksum(s.vfs, filepath.Join(s.dir, filepath.FromSlash(rel)))
+			if err != nil {
+				return false, err
+			}
+			sums[i] = sum
+		}
+		if sums[0] != sums[1] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// vfsChecksum is fileChecksum for any backend.
+func vfsChecksum(vfs vfsHandler, path string) ([32]byte, error) {
+	var sum [32]byte
+	f, err := vfs.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, fmt.Errorf("%s: %w", path, err)
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// compareSelects reports whether a mark means "copy this to the other side".
+func compareSelects(mark string) bool {
+	switch mark {
+		case markOnly, markNewer, markLarger, markDiffers:
+			return true
+	}
+	return false
+}
+
+// handleCompareMsg marks and selects the differences in both panels, unless
+// either panel has moved on since the compare started.
+func (m *Model) handleCompareMsg(msg compareMsg) {
+	if msg.gen != m.compareGen {
+		return
+	}
+	if msg.err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("compare: %v", msg.err))
+		return
+	}
+	for i := range m.panels {
+		if m.panels[i].vfs != msg.sides[i].vfs || m.panels[i].currentDir != msg.sides[i].dir {
+			m.statusMsg = warnStyle.Render("compare: a panel changed directory, run it again")
+			return
+		}
+	}
+	var summary [2]string
+	for i := range m.panels {
+		p := &m.panels[i]
+		p.compareDir = p.currentDir
+		p.compareMarks = msg.marks[i]
+		p.selectedFiles = make(map[string]bool)
+		counts := map[string]int{}
+		for name, mark := range msg.marks[i] {
+			counts[mark]++
+			if compareSelects(mark) {
+				p.selectedFiles[filepath.Join(p.currentDir, name)] = true
+			}
+		}
+		var parts []string
+		for _, mark := range []string{markOnly, markNewer, markLarger, markDiffers, markInside, markSkipped} {
+			if counts[mark] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[mark], mark))
+			}
+		}
+		if len(parts) == 0 {
+			parts = []string{"nothing to copy"}
+		}
+		summary[i] = strings.Join(parts, ", ")
+		m.refreshPanel(i)
+	}
+	if len(msg.marks[0])+len(msg.marks[1]) == 0 {
+		m.statusMsg = successStyle.Render("Directories are identical")
+		return
+	}
+	m.statusMsg = warnStyle.Render(fmt.Sprintf("Left: %s  │  Right: %s  – F5 copies the selection", summary[0], summary[1]))
+}
+
+// clearCompare drops the marks and the selection they made.
+func (m *Model) clearCompare() {
+	for i := range m.panels {
+		p := &m.panels[i]
+		if p.compareMarks == nil {
+			continue
+		}
+		p.compareMarks, p.compareDir = nil, ""
+		p.selectedFiles = make(map[string]bool)
+		m.refreshPanel(i)
+	}
+	m.statusMsg = successStyle.Render("Compare marks cleared")
+}
@@ -0,0 +1,599 @@
+package src
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Line diff (Myers) ────────────────────────────────────────────────────────
+
+// diffMaxEdits bounds the Myers search; beyond it the differing middle is
+// shown as one replacement instead.
+const diffMaxEdits = 4000
+
+// diffOp is one line of an edit script: ' ' keeps a[a] == b[b], '-' drops
+// a[a], '+' adds b[b].
+type diffOp struct {
+	kind byte
+	a, b int
+}
+
+// diffLines returns an edit script turning a into b.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []diffOp
+	for i := 0; i < pre; i++ {
+		ops = append(ops, diffOp{' ', i, i})
+	}
+	ops = append(ops, myers(a[pre:len(a)-suf], b[pre:len(b)-suf], pre, pre)...)
+	for i := suf; i > 0; i-- {
+		ops = append(ops, diffOp{' ', len(a) - i, len(b) - i})
+	}
+	return ops
+}
+
+func myers(a, b []string, offA, offB int) []diffOp {
+	n, m := len(a), len(b)
+	if n+m == 0 {
+		return nil
+	}
+	replace := func() []diffOp {
+		ops := make([]diffOp, 0, n+m)
+		for i := range a {
+			ops = append(ops, diffOp{'-', offA + i, -1})
+		}
+		for j := range b {
+			ops = append(ops, diffOp{'+', -1, offB + j})
+		}
+		return ops
+	}
+	max := n + m
+	v := make([]int, 2*max+3)
+	off := max + 1
+	// trace[d] holds v[-d-1..d+1] as it was before step d.
+	var trace [][]int
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		if d > diffMaxEdits {
+			return replace()
+		}
+		trace = append(trace, append([]int(nil), v[off-d-1:off+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	var rev []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		at := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, diffOp{' ', offA + x, offB + y})
+		}
+		if d > 0 {
+			if x == prevX {
+				rev = append(rev, diffOp{'+', -1, offB + y - 1})
+			} else {
+				rev = append(rev, diffOp{'-', offA + x - 1, -1})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	ops := make([]diffOp, len(rev))
+	for i, op := range rev {
+		ops[len(rev)-1-i] = op
+	}
+	return ops
+}
+
+// diffHunk is a run of changes: lines [aStart,aEnd) of a became
+// [bStart,bEnd) of b.
+type diffHunk struct {
+	aStart, aEnd int
+	bStart, bEnd int
+}
+
+// diffHunks groups consecutive changes; ops must come from diffLines.
+func diffHunks(ops []diffOp) []diffHunk {
+	var hunks []diffHunk
+	a, b := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			a, b = ops[i].a+1, ops[i].b+1
+			i++
+			continue
+		}
+		h := diffHunk{aStart: a, aEnd: a, bStart: b, bEnd: b}
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			if ops[i].kind == '-' {
+				h.aEnd = ops[i].a + 1
+			} else {
+				h.bEnd = ops[i].b + 1
+			}
+		}
+		a, b = h.aEnd, h.bEnd
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// ─── Diff viewer ──────────────────────────────────────────────────────────────
+
+type diffSide struct {
+	vfs    vfsHandler
+	path   string
+	lines  []string
+	styled []string // chroma-highlighted copies of lines
+	format textFormat
+	eol    bool // the last line ends with a newline
+	dirty  bool
+}
+
+// diffRow is one screen row; a and b are line indexes or -1, hunk is -1 for
+// unchanged lines.
+type diffRow struct {
+	a, b int
+	kind byte
+	hunk int
+}
+
+type diffView struct {
+	left, right diffSide
+	ops         []diffOp
+	hunks       []diffHunk
+	rows        []diffRow
+	unified     bool
+	top         int
+	hunk        int
+	width       int
+	height      int
+}
+
+func loadDiffSide(vfs vfsHandler, path string) (diffSide, error) {
+	s := diffSide{vfs: vfs, path: path}
+	stat, err := vfs.Stat(path)
+	if err != nil {
+		return s, err
+	}
+	if stat.IsDir() {
+		return s, fmt.Errorf("%s is a directory", filepath.Base(path))
+	}
+	if stat.Size() > maxFileSizeForEdit {
+		return s, fmt.Errorf("%s is too large to diff (>10MB)", filepath.Base(path))
+	}
+	f, err := vfs.Open(path)
+	if err != nil {
+		return s, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return s, err
+	}
+	s.format = detectFormat(data)
+	if !s.format.isUTF16() && looksBinary(data) {
+		return s, fmt.Errorf("%s is a binary file", filepath.Base(path))
+	}
+	text, err := decodeText(data, s.format)
+	if err != nil {
+		return s, err
+	}
+	s.lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	// An empty file gets the usual newline once something is copied into it.
+	s.eol = text == "" || strings.HasSuffix(text, "\n")
+	if text == "" {
+		s.lines = nil
+	}
+	s.highlight()
+	return s, nil
+}
+
+// highlight refreshes styled; lines keep their colours only when the lexer
+// output splits back into the same number of lines.
+func (s *diffSide) highlight() {
+	s.styled = nil
+	lexer := lexers.Match(filepath.Base(s.path))
+	if lexer == nil {
+		return
+	}
+	text := strings.ReplaceAll(strings.Join(s.lines, "\n"), "\t", "    ")
+	iterator, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return
+	}
+	var sb strings.Builder
+	if err := chromaFormatter.Format(&sb, chromaStyle, iterator); err != nil {
+		return
+	}
+	styled := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	if len(styled) == len(s.lines) {
+		s.styled = styled
+	}
+}
+
+func (s *diffSide) line(i int) string {
+	if s.styled != nil {
+		return s.styled[i]
+	}
+	return strings.ReplaceAll(s.lines[i], "\t", "    ")
+}
+
+func (s *diffSide) save() error {
+	text := strings.Join(s.lines, "\n")
+	if len(s.lines) > 0 && s.eol {
+		text += "\n"
+	}
+	out, err := encodeText(text, s.format)
+	if err != nil {
+		return err
+	}
+	w, err := s.vfs.Create(s.path)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(out); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// openDiff compares the cursor file of each panel; leftName/rightName
+// override them (relative to their panel's directory).
+func (m *Model) openDiff(leftName, rightName string) {
+	pick := func(idx int, name string) (vfsHandler, string, error) {
+		p := &m.panels[idx]
+		if name == "" {
+			sel, ok := p.fileList.SelectedItem().(item)
+			if !ok {
+				return nil, "", fmt.Errorf("no file under the cursor in the %s panel", [...]string{"left", "right"}[idx])
+			}
+			name = sel.title
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(p.currentDir, name)
+		}
+		return p.vfs, name, nil
+	}
+	lv, lp, err := pick(0, leftName)
+	if err == nil {
+		var rv vfsHandler
+		var rp string
+		if rv, rp, err = pick(1, rightName); err == nil {
+			var left, right diffSide
+			if left, err = loadDiffSide(lv, lp); err == nil {
+				if right, err = loadDiffSide(rv, rp); err == nil {
+					w, h := m.editorSize()
+					m.diff = diffView{left: left, right: right, width: w, height: h}
+					m.diff.recompute()
+					m.commandInput.Blur()
+					m.mode = diffMode
+					if len(m.diff.hunks) == 0 {
+						m.statusMsg = successStyle.Render("Files are identical")
+					} else {
+						m.statusMsg = ""
+						m.diff.gotoHunk(0)
+					}
+					return
+				}
+			}
+		}
+	}
+	m.statusMsg = errorStyle.Render(fmt.Sprintf("diff: %v", err))
+}
+
+func (d *diffView) recompute() {
+	d.ops = diffLines(d.left.lines, d.right.lines)
+	d.hunks = diffHunks(d.ops)
+	d.buildRows()
+	if d.hunk >= len(d.hunks) {
+		d.hunk = len(d.hunks) - 1
+	}
+	if d.hunk < 0 {
+		d.hunk = 0
+	}
+	d.clampTop()
+}
+
+// buildRows lays out ops: unified lists every op, side-by-side pairs each
+// removed line with an added one.
+func (d *diffView) buildRows() {
+	d.rows = d.rows[:0]
+	hunk := -1
+	for i := 0; i < len(d.ops); {
+		op := d.ops[i]
+		if op.kind == ' ' {
+			d.rows = append(d.rows, diffRow{op.a, op.b, ' ', -1})
+			i++
+			continue
+		}
+		hunk++
+		var dels, adds []int
+		for ; i < len(d.ops) && d.ops[i].kind != ' '; i++ {
+			if d.ops[i].kind == '-' {
+				dels = append(dels, d.ops[i].a)
+			} else {
+				adds = append(adds, d.ops[i].b)
+			}
+		}
+		if d.unified {
+			for _, a := range dels {
+				d.rows = append(d.rows, diffRow{a, -1, '-', hunk})
+			}
+			for _, b := range adds {
+				d.rows = append(d.rows, diffRow{-1, b, '+', hunk})
+			}
+			continue
+		}
+		for j := 0; j < len(dels) || j < len(adds); j++ {
+			r := diffRow{-1, -1, '~', hunk}
+			if j < len(dels) {
+				r.a = dels[j]
+			}
+			if j < len(adds) {
+				r.b = adds[j]
+			}
+			d.rows = append(d.rows, r)
+		}
+	}
+}
+
+func (d *diffView) clampTop() {
+	d.top = max(min(d.top, len(d.rows)-d.height), 0)
+}
+
+// gotoHunk scrolls so hunk i starts a few rows below the top.
+func (d *diffView) gotoHunk(i int) {
+	if i < 0 || i >= len(d.hunks) {
+		return
+	}
+	d.hunk = i
+	for r, row := range d.rows {
+		if row.hunk == i {
+			d.top = r - 3
+			break
+		}
+	}
+	d.clampTop()
+}
+
+// copyHunk replaces the current hunk on the target side with the source
+// side's lines; toRight copies left → right.
+func (d *diffView) copyHunk(toRight bool) bool {
+	if d.hunk >= len(d.hunks) {
+		return false
+	}
+	h := d.hunks[d.hunk]
+	src, dst := &d.left, &d.right
+	from, to := [2]int{h.aStart, h.aEnd}, [2]int{h.bStart, h.bEnd}
+	if !toRight {
+		src, dst = dst, src
+		from, to = to, from
+	}
+	lines := append([]string{}, dst.lines[:to[0]]...)
+	lines = append(lines, src.lines[from[0]:from[1]]...)
+	dst.lines = append(lines, dst.lines[to[1]:]...)
+	dst.dirty = true
+	dst.highlight()
+	d.recompute()
+	return true
+}
+
+func (m *Model) closeDiff() {
+	m.mode = explorerMode
+	m.commandInput.Focus()
+	m.refreshPanel(0)
+	m.refreshPanel(1)
+}
+
+func (m *Model) saveDiff() {
+	var saved []string
+	for _, s := range []*diffSide{&m.diff.left, &m.diff.right} {
+		if !s.dirty {
+			continue
+		}
+		if err := s.save(); err != nil {
+			m.statusMsg = errorStyle.Render(fmt.Sprintf("Save %s: %v", s.path, err))
+			return
+		}
+		saved = append(saved, s.vfs.VFSName()+s.path)
+	}
+	if len(saved) == 0 {
+		m.statusMsg = warnStyle.Render("Nothing to save")
+		return
+	}
+	m.statusMsg = successStyle.Render("Saved " + strings.Join(saved, ", "))
+}
+
+func (m *Model) updateDiff(msg tea.KeyMsg) tea.Cmd {
+	d := &m.diff
+	switch {
+		case key.Matches(msg, m.keys.cancel), msg.String() == "q":
+			if d.left.dirty || d.right.dirty {
+				m.askConfirm("Discard unsaved diff changes? (y/n)", func(m *Model) tea.Cmd {
+					m.closeDiff()
+					return nil
+				})
+				return nil
+			}
+			m.closeDiff()
+			return nil
+		case key.Matches(msg, m.keys.save):
+			m.saveDiff()
+			return nil
+		case key.Matches(msg, m.keys.down):
+			d.top++
+		case key.Matches(msg, m.keys.up):
+			d.top--
+		case key.Matches(msg, m.keys.tab), msg.String() == "u":
+			d.unified = !d.unified
+			d.buildRows()
+			d.gotoHunk(d.hunk)
+			return nil
+	}
+	switch msg.String() {
+		case "pgdown", " ":
+			d.top += d.height - 1
+		case "pgup", "b":
+			d.top -= d.height - 1
+		case "g", "home":
+			d.top = 0
+		case "G", "end":
+			d.top = len(d.rows)
+		case "n":
+			d.gotoHunk(d.hunk + 1)
+			return nil
+		case "N", "p":
+			d.gotoHunk(d.hunk - 1)
+			return nil
+		case ">", "<":
+			if d.copyHunk(msg.String() == ">") {
+				m.statusMsg = warnStyle.Render(fmt.Sprintf("Hunk copied %s – Ctrl+S to save", map[string]string{">": "left → right", "<": "right → left"}[msg.String()]))
+				d.gotoHunk(d.hunk)
+			}
+			return nil
+	}
+	d.clampTop()
+	return nil
+}
+
+// ─── Diff view ────────────────────────────────────────────────────────────────
+
+// fitCell cuts or pads s (which may contain ANSI styling) to exactly w cells.
+func fitCell(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	s = lipgloss.NewStyle().MaxWidth(w).Render(s)
+	return s + strings.Repeat(" ", max(w-lipgloss.Width(s), 0))
+}
+
+func (d *diffView) view() string {
+	if len(d.rows) == 0 {
+		return metaKeyStyle.Render("(both files are empty)")
+	}
+	var sb strings.Builder
+	end := min(d.top+d.height, len(d.rows))
+	for i := d.top; i < end; i++ {
+		if i > d.top {
+			sb.WriteByte('\n')
+		}
+		row := d.rows[i]
+		cur := " "
+		if row.hunk >= 0 && row.hunk == d.hunk {
+			cur = dirStyle.Render(selectedMarker)
+		}
+		sb.WriteString(cur)
+		if d.unified {
+			sb.WriteString(d.unifiedRow(row))
+		} else {
+			sb.WriteString(d.sideRow(row))
+		}
+	}
+	return sb.String()
+}
+
+func diffNumber(n int, style lipgloss.Style) string {
+	if n < 0 {
+		return "     "
+	}
+	return style.Render(fmt.Sprintf("%5d", n+1))
+}
+
+func (d *diffView) sideRow(row diffRow) string {
+	cw := (d.width - 4) / 2
+	cell := func(s *diffSide, n int, mark string, style lipgloss.Style) string {
+		if n < 0 {
+			return fitCell(hexZeroStyle.Render(strings.Repeat("╱", 4)), cw)
+		}
+		if row.kind == ' ' {
+			style, mark = metaKeyStyle, " "
+		}
+		return fitCell(diffNumber(n, style)+style.Render(mark)+" "+s.line(n), cw)
+	}
+	return cell(&d.left, row.a, "-", gitDeletedStyle) + hexZeroStyle.Render(" │ ") + cell(&d.right, row.b, "+", gitAddedStyle)
+}
+
+func (d *diffView) unifiedRow(row diffRow) string {
+	switch row.kind {
+		case '-':
+			return fitCell(diffNumber(row.a, gitDeletedStyle)+"      "+gitDeletedStyle.Render("- ")+d.left.line(row.a), d.width-1)
+		case '+':
+			return fitCell("      "+diffNumber(row.b, gitAddedStyle)+gitAddedStyle.Render("+ ")+d.right.line(row.b), d.width-1)
+	}
+	return fitCell(diffNumber(row.a, metaKeyStyle)+" "+diffNumber(row.b, metaKeyStyle)+"  "+d.right.line(row.b), d.width-1)
+}
+
+func (d *diffView) title() string {
+	name := func(s *diffSide) string {
+		n := s.vfs.VFSName() + s.path
+		if s.dirty {
+			n += " [+]"
+		}
+		return n
+	}
+	return name(&d.left) + "  ⇄  " + name(&d.right)
+}
+
+func (d *diffView) statusLine() string {
+	adds, dels := 0, 0
+	for _, op := range d.ops {
+		switch op.kind {
+			case '+':
+				adds++
+			case '-':
+				dels++
+		}
+	}
+	mode := "side-by-side"
+	if d.unified {
+		mode = "unified"
+	}
+	pos := "no differences"
+	if len(d.hunks) > 0 {
+		pos = fmt.Sprintf("hunk %d/%d", d.hunk+1, len(d.hunks))
+	}
+	return fmt.Sprintf("%s  │  %s  │  +%d -%d  │  row %d/%d", mode, pos, adds, dels, min(d.top+1, len(d.rows)), len(d.rows))
+}
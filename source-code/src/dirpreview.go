@@ -0,0 +1,160 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ─── Directory preview ────────────────────────────────────────────────────────
+
+const (
+	dirTreeDepth    = 2      // levels shown, like tree -L 2
+	dirTreeFanout   = 24     // entries listed per directory before "… N more"
+	dirTreeMaxLines = 200    // overall cap on tree lines
+	dirStatsMax     = 200000 // entries visited by the background size scan
+)
+
+// dirPreview draws a depth-limited tree of dir right away and leaves the
+// recursive size scan to the background.
+func dirPreview(ctx context.Context, vfs vfsHandler, dir string) previewResult {
+	entries, err := vfs.ReadDir(dir)
+	if err != nil {
+		return previewResult{content: fmt.Sprintf("📁 Directory (unreadable)\n%v", err)}
+	}
+	var sb strings.Builder
+	lines := 0
+	drawTree(ctx, vfs, dir, entries, "", 1, &sb, &lines)
+	tree := sb.String()
+
+	dirs, files := 0, 0
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs++
+		} else {
+			files++
+		}
+	}
+	head := fmt.Sprintf("📁 %s/\n%d subdirectories · %d files\n", filepath.Base(dir), dirs, files)
+	return previewResult{
+		content: head + metaKeyStyle.Render("Total size: computing…") + "\n\n" + tree,
+		finish: func(ctx context.Context) previewResult {
+			return previewResult{content: head + dirStats(ctx, vfs, dir).String() + "\n\n" + tree}
+		},
+	}
+}
+
+// sortTreeEntries lists directories first, then files, by name.
+func sortTreeEntries(entries []fs.DirEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
+	})
+}
+
+func drawTree(ctx context.Context, vfs vfsHandler, dir string, entries []fs.DirEntry, indent string, depth int, sb *strings.Builder, lines *int) {
+	sortTreeEntries(entries)
+	for i, e := range entries {
+		if ctx.Err() != nil || *lines >= dirTreeMaxLines {
+			return
+		}
+		last := i == len(entries)-1
+		if i == dirTreeFanout && !last {
+			fmt.Fprintf(sb, "%s└── %s\n", indent, metaKeyStyle.Render(fmt.Sprintf("… %d more", len(entries)-i)))
+			*lines++
+			return
+		}
+		branch, childIndent := "├── ", indent+"│   "
+		if last {
+			branch, childIndent = "└── ", indent+"    "
+		}
+		*lines++
+		if !e.IsDir() {
+			size := ""
+			if info, err := e.Info(); err == nil {
+				size = "  " + metaKeyStyle.Render(humanSize(info.Size()))
+			}
+			fmt.Fprintf(sb, "%s%s%s%s\n", indent, branch, fileStyle.Render(e.Name()), size)
+			continue
+		}
+		if depth >= dirTreeDepth {
+			fmt.Fprintf(sb, "%s%s%s\n", indent, branch, dirStyle.Render(e.Name()+"/"))
+			continue
+		}
+		children, err := vfs.ReadDir(filepath.Join(dir, e.Name()))
+		if err != nil {
+			fmt.Fprintf(sb, "%s%s%s  %s\n", indent, branch, dirStyle.Render(e.Name()+"/"), errorStyle.Render("unreadable"))
+			continue
+		}
+		fmt.Fprintf(sb, "%s%s%s  %s\n", indent, branch, dirStyle.Render(e.Name()+"/"), metaKeyStyle.Render(fmt.Sprintf("(%d entries)", len(children))))
+		drawTree(ctx, vfs, filepath.Join(dir, e.Name()), children, childIndent, depth+1, sb, lines)
+	}
+}
+
+// dirSummary is the result of a recursive scan.
+type dirSummary struct {
+	size       int64
+	files      int
+	dirs       int
+	newest     string // path relative to the scanned directory
+	newestTime time.Time
+	partial    bool // stopped at dirStatsMax entries or unreadable subdirectories
+}
+
+// dirStats walks dir recursively through vfs. Symlinks are not followed.
+func dirStats(ctx context.Context, vfs vfsHandler, dir string) dirSummary {
+	var s dirSummary
+	visited := 0
+	var walk func(path, rel string)
+	walk = func(path, rel string) {
+		entries, err := vfs.ReadDir(path)
+		if err != nil {
+			s.partial = true
+			return
+		}
+		for _, e := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+			if visited++; visited > dirStatsMax {
+				s.partial = true
+				return
+			}
+			name := filepath.Join(rel, e.Name())
+			if e.IsDir() {
+				s.dirs++
+				walk(filepath.Join(path, e.Name()), name)
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			s.files++
+			s.size += info.Size()
+			if info.ModTime().After(s.newestTime) {
+				s.newest, s.newestTime = name, info.ModTime()
+			}
+		}
+	}
+	walk(dir, "")
+	return s
+}
+
+func (s dirSummary) String() string {
+	approx := ""
+	if s.partial {
+		approx = "≥ "
+	}
+	out := fmt.Sprintf("Total size: %s%s in %d files, %d directories", approx, humanSize(s.size), s.files, s.dirs)
+	if s.newest != "" {
+		out += fmt.Sprintf("\nNewest: %s  %s", s.newest, metaKeyStyle.Render(s.newestTime.Format("2006-01-02 15:04")))
+	}
+	return out
+}
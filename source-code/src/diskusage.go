@@ -0,0 +1,448 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Disk usage (ncdu-style) ──────────────────────────────────────────────────
+
+// duNode is one entry of a scanned tree; sizes of directories are cumulative.
+type duNode struct {
+	name     string
+	isDir    bool
+	size     int64
+	files    int
+	children []*duNode // largest first
+	parent   *duNode
+	err      error // ReadDir failure for this directory
+}
+
+func (n *duNode) path(root string) string {
+	var parts []string
+	for ; n.parent != nil; n = n.parent {
+		parts = append([]string{n.name}, parts...)
+	}
+	return filepath.Join(append([]string{root}, parts...)...)
+}
+
+// find returns the descendant at rel ("" is n itself).
+func (n *duNode) find(rel string) *duNode {
+	if rel == "" || rel == "." {
+		return n
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		var next *duNode
+		for _, c := range n.children {
+			if c.name == part && c.isDir {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
+
+// adjust propagates a size/file-count change to n and its ancestors.
+func (n *duNode) adjust(size int64, files int) {
+	for ; n != nil; n = n.parent {
+		n.size += size
+		n.files += files
+	}
+}
+
+func (n *duNode) sortChildren() {
+	sort.Slice(n.children, func(i, j int) bool {
+		if n.children[i].size != n.children[j].size {
+			return n.children[i].size > n.children[j].size
+		}
+		return n.children[i].name < n.children[j].name
+	})
+}
+
+// duScan is a finished scan; root is nil when it was cancelled.
+type duScan struct {
+	vfs  vfsHandler
+	path string
+	root *duNode
+}
+
+type duScanMsg struct {
+	gen  int
+	scan duScan
+}
+
+type duTickMsg struct{ gen int }
+
+// duDeleteMsg reports a delete started from the disk usage view.
+type duDeleteMsg struct {
+	root *duNode // tree the node belonged to
+	node *duNode
+	path string
+	err  error
+}
+
+type diskUsage struct {
+	vfs      vfsHandler
+	rootPath string
+	root     *duNode
+	cur      *duNode
+	cursor   int
+	offset   int
+	height   int
+	width    int
+	cached   bool
+
+	// background scan
+	scanning bool
+	scanPath string // directory being (re)scanned
+	gen      int
+	cancel   context.CancelFunc
+	files    *atomic.Int64
+	bytes    *atomic.Int64
+	started  time.Time
+}
+
+var duGen int
+
+// openDiskUsage shows disk usage of the active panel's directory, reusing an
+// earlier scan of it or of any parent directory on the same VFS.
+func (m *Model) openDiskUsage() tea.Cmd {
+	p := &m.panels[m.activePanel]
+	m.du.stop()
+	w, h := m.editorSize()
+	m.du = diskUsage{vfs: p.vfs, rootPath: p.currentDir, width: w, height: h}
+	m.commandInput.Blur()
+	m.mode = duMode
+	for _, s := range m.duScans {
+		if s.vfs != p.vfs {
+			continue
+		}
+		rel, err := filepath.Rel(s.path, p.currentDir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if n := s.root.find(rel); n != nil {
+			m.du.root, m.du.cur, m.du.rootPath = s.root, n, s.path
+			m.du.cached = true
+			return nil
+		}
+	}
+	return m.du.scanCmd(p.currentDir)
+}
+
+func (d *diskUsage) stop() {
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+	d.scanning = false
+}
+
+// scanCmd walks path in the background and reports progress through ticks.
+func (d *diskUsage) scanCmd(path string) tea.Cmd {
+	d.stop()
+	duGen++
+	ctx, cancel := context.WithCancel(context.Background())
+	d.gen, d.cancel = duGen, cancel
+	d.scanning, d.scanPath, d.started = true, path, time.Now()
+	d.files, d.bytes = new(atomic.Int64), new(atomic.Int64)
+	vfs, gen, files, bytes := d.vfs, d.gen, d.files, d.bytes
+	scan := func() tea.Msg {
+		root := &duNode{name: filepath.Base(path), isDir: true}
+		duWalk(ctx, vfs, path, root, files, bytes)
+		if ctx.Err() != nil {
+			return duScanMsg{gen: gen}
+		}
+		return duScanMsg{gen: gen, scan: duScan{vfs: vfs, path: path, root: root}}
+	}
+	return tea.Batch(scan, duTick(gen))
+}
+
+func duTick(gen int) tea.Cmd {
+	return tea.Tick(200*time.Millisecond, func(time.Time) tea.Msg { return duTickMsg{gen: gen} })
+}
+
+// duWalk fills n with the contents of path. Symlinks are counted, not followed.
+func duWalk(ctx context.Context, vfs vfsHandler, path string, n *duNode, files, bytes *atomic.Int64) {
+	entries, err := vfs.ReadDir(path)
+	if err != nil {
+		n.err = err
+		return
+	}
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		c := &duNode{name: e.Name(), isDir: e.IsDir(), parent: n}
+		if e.IsDir() {
+			duWalk(ctx, vfs, filepath.Join(path, e.Name()), c, files, bytes)
+		} else if info, err := e.Info(); err == nil {
+			c.size, c.files = info.Size(), 1
+			files.Add(1)
+			bytes.Add(c.size)
+		}
+		n.size += c.size
+		n.files += c.files
+		n.children = append(n.children, c)
+	}
+	n.sortChildren()
+}
+
+// rememberScan keeps s for later visits, dropping scans it supersedes.
+func (m *Model) rememberScan(s duScan) {
+	kept := m.duScans[:0]
+	for _, old := range m.duScans {
+		rel, err := filepath.Rel(s.path, old.path)
+		if old.vfs == s.vfs && err == nil && !strings.HasPrefix(rel, "..") {
+			continue
+		}
+		kept = append(kept, old)
+	}
+	m.duScans = append(kept, s)
+}
+
+func (m *Model) handleDiskUsageMsg(msg tea.Msg) tea.Cmd {
+	d := &m.du
+	switch msg := msg.(type) {
+		case duTickMsg:
+			if msg.gen != d.gen || !d.scanning {
+				return nil
+			}
+			return duTick(d.gen)
+		case duScanMsg:
+			if msg.gen != d.gen || msg.scan.root == nil {
+				return nil
+			}
+			d.scanning, d.cancel = false, nil
+			s := msg.scan
+			if d.root != nil && s.path != d.rootPath {
+				// Rescan of a subtree: splice it into the tree being shown.
+				rel, _ := filepath.Rel(d.rootPath, s.path)
+				if old := d.root.find(rel); old != nil && old.parent != nil {
+					s.root.name, s.root.parent = old.name, old.parent
+					for i, c := range old.parent.children {
+						if c == old {
+							old.parent.children[i] = s.root
+						}
+					}
+					old.parent.adjust(s.root.size-old.size, s.root.files-old.files)
+					for p := old.parent; p != nil; p = p.parent {
+						p.sortChildren()
+					}
+					d.cur = s.root
+					d.cursor, d.offset = 0, 0
+					m.statusMsg = successStyle.Render(fmt.Sprintf("Rescanned %s", s.path))
+					return nil
+				}
+			}
+			d.root, d.cur, d.rootPath, d.cached = s.root, s.root, s.path, false
+			d.cursor, d.offset = 0, 0
+			m.rememberScan(s)
+			m.statusMsg = successStyle.Render(fmt.Sprintf("Scanned %d files in %s", s.root.files, time.Since(d.started).Round(time.Millisecond)))
+	}
+	return nil
+}
+
+func (m *Model) closeDiskUsage() {
+	m.du.stop()
+	m.mode = explorerMode
+	m.commandInput.Focus()
+	m.refreshPanel(m.activePanel)
+}
+
+func (m *Model) updateDiskUsage(msg tea.KeyMsg) tea.Cmd {
+	d := &m.du
+	if d.cur == nil {
+		// Nothing to navigate until the first scan finishes.
+		if key.Matches(msg, m.keys.cancel) || msg.String() == "q" {
+			m.closeDiskUsage()
+		}
+		return nil
+	}
+	n := len(d.cur.children)
+	switch {
+		case key.Matches(msg, m.keys.cancel), msg.String() == "q":
+			m.closeDiskUsage()
+			return nil
+		case key.Matches(msg, m.keys.down):
+			d.cursor++
+		case key.Matches(msg, m.keys.up):
+			d.cursor--
+		case key.Matches(msg, m.keys.execute), key.Matches(msg, m.keys.right):
+			if d.cursor < n && d.cur.children[d.cursor].isDir {
+				d.cur = d.cur.children[d.cursor]
+				d.cursor, d.offset = 0, 0
+			}
+			return nil
+		case key.Matches(msg, m.keys.back), key.Matches(msg, m.keys.left):
+			if d.cur.parent != nil {
+				prev := d.cur
+				d.cur = d.cur.parent
+				d.cursor, d.offset = 0, 0
+				for i, c := range d.cur.children {
+					if c == prev {
+						d.cursor = i
+					}
+				}
+			}
+		case key.Matches(msg, m.keys.delete), msg.String() == "d":
+			if d.cursor < n {
+				m.promptDiskUsageDelete(d.cur.children[d.cursor])
+			}
+			return nil
+		case key.Matches(msg, m.keys.refresh):
+			return d.scanCmd(d.cur.path(d.rootPath))
+	}
+	switch msg.String() {
+		case "pgdown":
+			d.cursor += d.rows()
+		case "pgup":
+			d.cursor -= d.rows()
+		case "g", "home":
+			d.cursor = 0
+		case "G", "end":
+			d.cursor = n - 1
+	}
+	d.clamp()
+	return nil
+}
+
+func (d *diskUsage) rows() int { return max(d.height-2, 1) }
+
+func (d *diskUsage) clamp() {
+	n := len(d.cur.children)
+	d.cursor = max(min(d.cursor, n-1), 0)
+	if d.cursor < d.offset {
+		d.offset = d.cursor
+	}
+	if d.cursor >= d.offset+d.rows() {
+		d.offset = d.cursor - d.rows() + 1
+	}
+}
+
+// promptDiskUsageDelete removes node through the VFS after confirmation, in
+// the background, and then subtracts it from the scanned tree, so no rescan
+// is needed.
+func (m *Model) promptDiskUsageDelete(node *duNode) {
+	path := node.path(m.du.rootPath)
+	kind := "file"
+	if node.isDir {
+		kind = fmt.Sprintf("directory (%d files)", node.files)
+	}
+	m.askConfirm(fmt.Sprintf("Delete %s '%s', %s? (y/n)", kind, node.name, humanSize(node.size)), func(m *Model) tea.Cmd {
+		vfs, root := m.du.vfs, m.du.root
+		m.statusMsg = warnStyle.Render(fmt.Sprintf("Deleting %s…", path))
+		return func() tea.Msg {
+			return duDeleteMsg{root: root, node: node, path: path, err: vfs.Remove(path)}
+		}
+	})
+}
+
+// handleDiskUsageDelete drops a deleted node from the tree, if the tree is
+// still the one it was deleted from.
+func (m *Model) handleDiskUsageDelete(msg duDeleteMsg) {
+	if msg.err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("Delete %s: %v", msg.path, msg.err))
+		return
+	}
+	m.statusMsg = successStyle.Render(fmt.Sprintf("Deleted %s, freed %s", msg.path, humanSize(msg.node.size)))
+	d := &m.du
+	if d.root != msg.root {
+		return
+	}
+	parent := msg.node.parent
+	for i, c := range parent.children {
+		if c == msg.node {
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+			parent.adjust(-msg.node.size, -msg.node.files)
+			for n := d.cur; n != nil; n = n.parent {
+				if n == msg.node {
+					// The view moved into the node while it was deleted.
+					d.cur, d.cursor, d.offset = parent, 0, 0
+					break
+				}
+			}
+			d.clamp()
+			break
+		}
+	}
+}
+
+// ─── Disk usage view ──────────────────────────────────────────────────────────
+
+func (d *diskUsage) title() string {
+	if d.cur == nil {
+		return d.scanPath
+	}
+	return d.cur.path(d.rootPath)
+}
+
+func (d *diskUsage) view() string {
+	if d.cur == nil {
+		return metaKeyStyle.Render(fmt.Sprintf("Scanning %s…", d.scanPath))
+	}
+	if len(d.cur.children) == 0 {
+		if d.cur.err != nil {
+			return errorStyle.Render(d.cur.err.Error())
+		}
+		return metaKeyStyle.Render("(empty)")
+	}
+	const barW = 20
+	largest := d.cur.children[0].size
+	var sb strings.Builder
+	end := min(d.offset+d.rows(), len(d.cur.children))
+	for i := d.offset; i < end; i++ {
+		c := d.cur.children[i]
+		pct := 0.0
+		if d.cur.size > 0 {
+			pct = float64(c.size) * 100 / float64(d.cur.size)
+		}
+		fill := 0
+		if largest > 0 {
+			fill = int(float64(c.size) * barW / float64(largest))
+		}
+		bar := gitAddedStyle.Render(strings.Repeat("█", fill)) + hexZeroStyle.Render(strings.Repeat("░", barW-fill))
+		name := fileStyle.Render(c.name)
+		if c.isDir {
+			name = dirStyle.Render(c.name+"/") + metaKeyStyle.Render(fmt.Sprintf("  %d files", c.files))
+		}
+		if c.err != nil {
+			name += "  " + errorStyle.Render("unreadable")
+		}
+		prefix := "  "
+		if i == d.cursor {
+			prefix = dirStyle.Render(selectedMarker + " ")
+		}
+		fmt.Fprintf(&sb, "%s%10s %s %5.1f%%  %s\n", prefix, humanSize(c.size), bar, pct, name)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (d *diskUsage) statusLine() string {
+	parts := []string{}
+	if d.cur != nil {
+		parts = append(parts, fmt.Sprintf("%s in %d files", humanSize(d.cur.size), d.cur.files))
+		if len(d.cur.children) > 0 {
+			parts = append(parts, fmt.Sprintf("%d/%d", d.cursor+1, len(d.cur.children)))
+		}
+	}
+	if d.scanning {
+		parts = append(parts, fmt.Sprintf("scanning %s… %d files, %s", d.scanPath, d.files.Load(), humanSize(d.bytes.Load())))
+	} else if d.cached {
+		parts = append(parts, "cached scan (r to rescan)")
+	}
+	return strings.Join(parts, "  │  ")
+}
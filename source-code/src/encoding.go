@@ -0,0 +1,353 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ─── Text encoding & line endings ─────────────────────────────────────────────
+
+// textFormat describes how a text file is stored on disk. Buffers always hold
+// UTF-8 with LF line endings; the format is reapplied on save.
+type textFormat struct {
+	encoding string // canonical name, see textEncodings
+	bom      bool
+	crlf     bool
+}
+
+var defaultTextFormat = textFormat{encoding: "UTF-8"}
+
+// textEncodings lists the encodings the editor can cycle through and
+// convert to; any other IANA name is accepted by the encoding command.
+var textEncodings = []string{"UTF-8", "UTF-16LE", "UTF-16BE", "windows-1250", "windows-1252", "ISO-8859-1", "ISO-8859-2"}
+
+func (tf textFormat) String() string {
+	enc := tf.encoding
+	if tf.bom {
+		enc += " BOM"
+	}
+	eol := "LF"
+	if tf.crlf {
+		eol = "CRLF"
+	}
+	return enc + " · " + eol
+}
+
+func (tf textFormat) isUTF16() bool { return strings.HasPrefix(tf.encoding, "UTF-16") }
+
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToUpper(name) {
+		case "UTF-8", "UTF8":
+			return unicode.UTF8, nil
+		case "UTF-16LE":
+			return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+		case "UTF-16BE":
+			return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+		case "WINDOWS-1250", "CP1250":
+			return charmap.Windows1250, nil
+		case "WINDOWS-1252", "CP1252":
+			return charmap.Windows1252, nil
+		case "ISO-8859-1", "LATIN1":
+			return charmap.ISO8859_1, nil
+		case "ISO-8859-2", "LATIN2":
+			return charmap.ISO8859_2, nil
+	}
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+var encodingAliases = map[string]string{
+	"utf8":   "UTF-8",
+	"cp1250": "windows-1250",
+	"cp1252": "windows-1252",
+	"latin1": "ISO-8859-1",
+	"latin2": "ISO-8859-2",
+}
+
+// canonicalEncoding normalises user input such as "utf8" or "cp1250".
+func canonicalEncoding(name string) (string, error) {
+	if alias, ok := encodingAliases[strings.ToLower(name)]; ok {
+		return alias, nil
+	}
+	for _, e := range textEncodings {
+		if strings.EqualFold(e, name) {
+			return e, nil
+		}
+	}
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return "", err
+	}
+	if n, err := ianaindex.IANA.Name(enc); err == nil {
+		return n, nil
+	}
+	return name, nil
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// detectFormat guesses the encoding from a BOM, UTF-16 NUL patterns, UTF-8
+// validity and finally the Central European vs Western heuristic below.
+func detectFormat(data []byte) textFormat {
+	tf := textFormat{encoding: "UTF-8"}
+	switch {
+		case bytes.HasPrefix(data, bomUTF8):
+			tf.bom = true
+		case bytes.HasPrefix(data, bomUTF16LE):
+			tf.encoding, tf.bom = "UTF-16LE", true
+		case bytes.HasPrefix(data, bomUTF16BE):
+			tf.encoding, tf.bom = "UTF-16BE", true
+		default:
+			tf.encoding = guessEncoding(data)
+	}
+	sample := data
+	if tf.isUTF16() {
+		if dec, err := decodeBytes(data, tf); err == nil {
+			sample = []byte(dec)
+		}
+	}
+	crlf := bytes.Count(sample, []byte("\r\n"))
+	lf := bytes.Count(sample, []byte("\n"))
+	tf.crlf = crlf > 0 && crlf*2 >= lf
+	return tf
+}
+
+// cp1250Letters are bytes that are letters in windows-1250 (ą ł ś ż ź …)
+// but symbols in windows-1252/latin1 (£ ¥ ¼ ¿ …).
+var cp1250Letters = map[byte]bool{
+	0x8C: true, 0x8F: true, 0x9C: true, 0x9F: true, 0xA3: true, 0xA5: true,
+	0xAF: true, 0xB3: true, 0xB9: true, 0xBC: true, 0xBE: true, 0xBF: true,
+}
+
+// cp1250Scores weighs the evidence for windows-1250 against windows-1252
+// in legacy text. A cp1250Letters byte counts for 1250 when it sits next to
+// letters, as inside a word; standing alone, or used the way 1252 uses it
+// (¿ or ¡ opening a sentence, £ or ¥ before a number), it counts for 1252.
+func cp1250Scores(data []byte) (for1250, for1252 int) {
+	letter := func(i int) bool {
+		if i < 0 || i >= len(data) {
+			return false
+		}
+		b := data[i]
+		return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0xC0
+	}
+	digit := func(i int) bool { return i < len(data) && data[i] >= '0' && data[i] <= '9' }
+	upper := func(i int) bool { return i < len(data) && (data[i] >= 'A' && data[i] <= 'Z' || data[i] >= 0xC0 && data[i] < 0xDF) }
+	for i, b := range data {
+		switch {
+			case b == 0xA1 || b == 0xBF && !letter(i-1) && upper(i+1):
+				for1252 += 2 // ¡ ¿
+			case (b == 0xA3 || b == 0xA5) && digit(i+1):
+				for1252 += 2 // £5 ¥5
+			case !cp1250Letters[b]:
+			case letter(i-1) && letter(i+1):
+				for1250 += 2
+			case letter(i-1) || letter(i+1):
+				for1250++
+			default:
+				for1252++
+		}
+	}
+	return for1250, for1252
+}
+
+// trimPartialRune drops a multibyte UTF-8 sequence cut off at the end of
+// data, as left by reading only the head of a file.
+func trimPartialRune(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-(utf8.UTFMax-1); i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+func guessEncoding(data []byte) string {
+	if len(data) > 64*1024 {
+		data = data[:64*1024]
+	}
+	// UTF-16 without BOM: ASCII text leaves every other byte NUL
+	if len(data) >= 4 {
+		var even, odd int
+		for i, b := range data {
+			if b == 0 {
+				if i%2 == 0 {
+					even++
+				} else {
+					odd++
+				}
+			}
+		}
+		half := len(data) / 2
+		switch {
+			case odd > half*2/5 && even < half/10:
+				return "UTF-16LE"
+			case even > half*2/5 && odd < half/10:
+				return "UTF-16BE"
+		}
+	}
+	if utf8.Valid(trimPartialRune(data)) {
+		return "UTF-8"
+	}
+	c1 := false
+	for _, b := range data {
+		if b >= 0x80 && b < 0xA0 {
+			c1 = true
+		}
+	}
+	// Several 1250 letters in words, and clearly more of them than 1252
+	// signs, before switching away from the western encodings.
+	for1250, for1252 := cp1250Scores(data)
+	switch {
+		case for1250 >= 4 && for1250 > 2*for1252:
+			return "windows-1250"
+		case c1:
+			return "windows-1252"
+	}
+	return "ISO-8859-1"
+}
+
+// decodeBytes converts raw file content to UTF-8 without touching line endings.
+func decodeBytes(data []byte, tf textFormat) (string, error) {
+	switch {
+		case tf.bom && tf.encoding == "UTF-8":
+			data = bytes.TrimPrefix(data, bomUTF8)
+		case tf.bom && tf.encoding == "UTF-16LE":
+			data = bytes.TrimPrefix(data, bomUTF16LE)
+		case tf.bom && tf.encoding == "UTF-16BE":
+			data = bytes.TrimPrefix(data, bomUTF16BE)
+	}
+	if tf.encoding == "UTF-8" {
+		return string(data), nil
+	}
+	enc, err := lookupEncoding(tf.encoding)
+	if err != nil {
+		return "", err
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	return string(out), err
+}
+
+// decodeText returns editor-ready text: UTF-8 with LF line endings.
+func decodeText(data []byte, tf textFormat) (string, error) {
+	s, err := decodeBytes(data, tf)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(s, "\r\n", "\n"), nil
+}
+
+// encodeText is the inverse of decodeText.
+func encodeText(s string, tf textFormat) ([]byte, error) {
+	if tf.crlf {
+		s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+	}
+	var out []byte
+	if tf.encoding == "UTF-8" {
+		out = []byte(s)
+	} else {
+		enc, err := lookupEncoding(tf.encoding)
+		if err != nil {
+			return nil, err
+		}
+		out, err = enc.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode as %s: %w", tf.encoding, err)
+		}
+	}
+	if tf.bom {
+		switch tf.encoding {
+			case "UTF-8":
+				out = append(append([]byte{}, bomUTF8...), out...)
+			case "UTF-16LE":
+				out = append(append([]byte{}, bomUTF16LE...), out...)
+			case "UTF-16BE":
+				out = append(append([]byte{}, bomUTF16BE...), out...)
+		}
+	}
+	return out, nil
+}
+
+// nextEncoding cycles through textEncodings; UTF-8 is offered with and
+// without BOM, UTF-16 always carries one.
+func nextEncoding(tf textFormat) textFormat {
+	if tf.encoding == "UTF-8" && !tf.bom {
+		tf.bom = true
+		return tf
+	}
+	idx := 0
+	for i, e := range textEncodings {
+		if e == tf.encoding {
+			idx = i
+		}
+	}
+	tf.encoding = textEncodings[(idx+1)%len(textEncodings)]
+	tf.bom = tf.isUTF16()
+	return tf
+}
+
+// ─── Convert files on disk ────────────────────────────────────────────────────
+
+// convertFile rewrites path through vfs with the format changed by apply.
+func (m *Model) convertFile(vfs vfsHandler, path string, apply func(textFormat) textFormat) (textFormat, error) {
+	stat, err := vfs.Stat(path)
+	if err != nil {
+		return textFormat{}, err
+	}
+	if stat.Size() > maxFileSizeForEdit {
+		return textFormat{}, fmt.Errorf("file too large to convert (>10MB)")
+	}
+	f, err := vfs.Open(path)
+	if err != nil {
+		return textFormat{}, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return textFormat{}, err
+	}
+	from := detectFormat(data)
+	text, err := decodeText(data, from)
+	if err != nil {
+		return textFormat{}, err
+	}
+	to := apply(from)
+	out, err := encodeText(text, to)
+	if err != nil {
+		return textFormat{}, err
+	}
+	w, err := vfs.Create(path)
+	if err != nil {
+		return textFormat{}, err
+	}
+	if _, err := w.Write(out); err != nil {
+		w.Close()
+		return textFormat{}, err
+	}
+	if err := w.Close(); err != nil {
+		return textFormat{}, err
+	}
+	if i := m.findBuffer(vfs, path); i >= 0 {
+		m.buffers[i].format = to
+		m.buffers[i].savedFormat = to
+	}
+	return to, nil
+}
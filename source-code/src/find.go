@@ -0,0 +1,481 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/sftp"
+)
+
+// ─── Find ─────────────────────────────────────────────────────────────────────
+
+const (
+	findMaxResults = 10000
+	findMaxDepth   = 64
+)
+
+const findUsage = "usage: find [DIR] [!] [-name GLOB] [-iname GLOB] [-path GLOB] [-type f|d|l] [-size ±N[kMG]] [-mtime ±N[smhdw]] [-newer DATE] [-perm [-/]MODE] [-user U] [-group G] [-maxdepth N]"
+
+// findEntry is one match. Its name is the path relative to the search root,
+// so everything that joins the panel's currentDir with an item title (copy,
+// move, delete, preview, edit) works on find results unchanged.
+type findEntry struct {
+	rel  string
+	info fs.FileInfo
+}
+
+func (e findEntry) Name() string               { return e.rel }
+func (e findEntry) IsDir() bool                { return e.info.IsDir() }
+func (e findEntry) Type() fs.FileMode          { return e.info.Mode().Type() }
+func (e findEntry) Info() (fs.FileInfo, error) { return e.info, nil }
+
+// findListing is a panel's virtual directory: the results of query, rooted
+// at the panel's currentDir.
+type findListing struct {
+	query   string
+	vfs     vfsHandler
+	root    string
+	entries []findEntry
+}
+
+// findPred is one predicate; all of them must hold for a match.
+type findPred func(rel string, info fs.FileInfo) bool
+
+// findMsg delivers the results of a find started from panel idx.
+type findMsg struct {
+	gen     int
+	idx     int
+	from    string
+	listing *findListing
+	limited bool
+	err     error
+}
+
+// restat drops entries that no longer exist and refreshes the rest, reading
+// each parent directory once.
+func (l *findListing) restat() []fs.DirEntry {
+	dirs := make(map[string]map[string]fs.FileInfo)
+	var kept []findEntry
+	for _, e := range l.entries {
+		parent := filepath.Dir(e.rel)
+		infos, ok := dirs[parent]
+		if !ok {
+			infos = make(map[string]fs.FileInfo)
+			if entries, err := l.vfs.ReadDir(filepath.Join(l.root, parent)); err == nil {
+				for _, de := range entries {
+					if info, err := de.Info(); err == nil {
+						infos[de.Name()] = info
+					}
+				}
+			}
+			dirs[parent] = infos
+		}
+		if info, ok := infos[filepath.Base(e.rel)]; ok {
+			kept = append(kept, findEntry{rel: e.rel, info: info})
+		}
+	}
+	l.entries = kept
+	files := make([]fs.DirEntry, len(kept))
+	for i, e := range kept {
+		files[i] = e
+	}
+	return files
+}
+
+// renamed follows an entry renamed from one absolute path to another.
+func (l *findListing) renamed(from, to string) {
+	for i := range l.entries {
+		if filepath.Join(l.root, l.entries[i].rel) != from {
+			continue
+		}
+		if rel, err := filepath.Rel(l.root, to); err == nil {
+			l.entries[i].rel = rel
+		}
+		return
+	}
+}
+
+// parseFindArgs parses find's predicates. An optional leading DIR names the
+// search root; "!" or -not negates the predicate after it.
+func parseFindArgs(args []string) (dir string, preds []findPred, maxDepth int, err error) {
+	maxDepth = findMaxDepth
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") && args[0] != "!" {
+		dir, args = args[0], args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		negate := false
+		if args[i] == "!" || args[i] == "-not" {
+			negate = true
+			if i++; i == len(args) {
+				return "", nil, 0, fmt.Errorf("%s needs a predicate", args[i-1])
+			}
+		}
+		flag := args[i]
+		if i+1 == len(args) {
+			return "", nil, 0, fmt.Errorf("%s needs a value", flag)
+		}
+		i++
+		val := args[i]
+		var pred findPred
+		switch flag {
+			case "-name", "-iname":
+				fold := flag == "-iname"
+				if fold {
+					val = strings.ToLower(val)
+				}
+				if _, err := filepath.Match(val, ""); err != nil {
+					return "", nil, 0, fmt.Errorf("%s %s: %v", flag, val, err)
+				}
+				pred = func(rel string, _ fs.FileInfo) bool {
+					name := filepath.Base(rel)
+					if fold {
+						name = strings.ToLower(name)
+					}
+					ok, _ := filepath.Match(val, name)
+					return ok
+				}
+			case "-path":
+				re, err := regexp.Compile("^" + globToRegexp(filepath.ToSlash(val)) + "$")
+				if err != nil {
+					return "", nil, 0, fmt.Errorf("-path %s: %v", val, err)
+				}
+				pred = func(rel string, _ fs.FileInfo) bool { return re.MatchString(filepath.ToSlash(rel)) }
+			case "-type":
+				switch val {
+					case "f":
+						pred = func(_ string, info fs.FileInfo) bool { return info.Mode().IsRegular() }
+					case "d":
+						pred = func(_ string, info fs.FileInfo) bool { return info.IsDir() }
+					case "l":
+						pred = func(_ string, info fs.FileInfo) bool { return info.Mode()&fs.ModeSymlink != 0 }
+					default:
+						return "", nil, 0, fmt.Errorf("-type %s: want f, d or l", val)
+				}
+			case "-size":
+				pred, err = parseFindSize(val)
+			case "-mtime":
+				pred, err = parseFindAge(val)
+			case "-newer":
+				var t time.Time
+				for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+					if t, err = time.ParseInLocation(layout, val, time.Local); err == nil {
+						break
+					}
+				}
+				if err != nil {
+					return "", nil, 0, fmt.Errorf("-newer %s: want YYYY-MM-DD[THH:MM[:SS]]", val)
+				}
+				pred = func(_ string, info fs.FileInfo) bool { return info.ModTime().After(t) }
+			case "-perm":
+				pred, err = parseFindPerm(val)
+			case "-user", "-group":
+				pred, err = parseFindOwner(flag == "-group", val)
+			case "-maxdepth":
+				if maxDepth, err = strconv.Atoi(val); err != nil || maxDepth < 1 {
+					return "", nil, 0, fmt.Errorf("-maxdepth %s: want a positive number", val)
+				}
+				if negate {
+					return "", nil, 0, fmt.Errorf("-maxdepth cannot be negated")
+				}
+				continue
+			default:
+				return "", nil, 0, fmt.Errorf("unknown predicate %s", flag)
+		}
+		if err != nil {
+			return "", nil, 0, err
+		}
+		if negate {
+			p := pred
+			pred = func(rel string, info fs.FileInfo) bool { return !p(rel, info) }
+		}
+		preds = append(preds, pred)
+	}
+	return dir, preds, maxDepth, nil
+}
+
+// splitFindSign splits the +/- comparison prefix off a numeric argument.
+func splitFindSign(val string) (sign byte, rest string) {
+	if val != "" && (val[0] == '+' || val[0] == '-') {
+		return val[0], val[1:]
+	}
+	return 0, val
+}
+
+// parseFindSize parses +N (larger than), -N (smaller than) or N (that many
+// units, rounded up) with an optional k, M or G suffix.
+func parseFindSize(val string) (findPred, error) {
+	sign, num := splitFindSign(val)
+	unit := int64(1)
+	if num != "" {
+		switch num[len(num)-1] {
+			case 'k', 'K':
+				unit = 1 << 10
+			case 'M':
+				unit = 1 << 20
+			case 'G':
+				unit = 1 << 30
+		}
+		if unit > 1 {
+			num = num[:len(num)-1]
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("-size %s: want ±N[kMG]", val)
+	}
+	limit := n * unit
+	switch sign {
+		case '+':
+			return func(_ string, info fs.FileInfo) bool { return info.Size() > limit }, nil
+		case '-':
+			return func(_ string, info fs.FileInfo) bool { return info.Size() < limit }, nil
+	}
+	return func(_ string, info fs.FileInfo) bool { return (info.Size()+unit-1)/unit == n }, nil
+}
+
+// parseFindAge parses -N (modified less than N ago), +N (more than N ago) or
+// N (between N and N+1 ago), in s, m, h, d (the default) or w.
+func parseFindAge(val string) (findPred, error) {
+	sign, num := splitFindSign(val)
+	unit := 24 * time.Hour
+	if num != "" {
+		switch num[len(num)-1] {
+			case 's':
+				unit = time.Second
+			case 'm':
+				unit = time.Minute
+			case 'h':
+				unit = time.Hour
+			case 'd':
+			case 'w':
+				unit = 7 * 24 * time.Hour
+			default:
+				num += "d" // bare numbers are days
+		}
+		num = num[:len(num)-1]
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("-mtime %s: want ±N[smhdw]", val)
+	}
+	d := time.Duration(n) * unit
+	now := time.Now()
+	switch sign {
+		case '+':
+			return func(_ string, info fs.FileInfo) bool { return now.Sub(info.ModTime()) > d }, nil
+		case '-':
+			return func(_ string, info fs.FileInfo) bool { return now.Sub(info.ModTime()) < d }, nil
+	}
+	return func(_ string, info fs.FileInfo) bool {
+		age := now.Sub(info.ModTime())
+		return age >= d && age < d+unit
+	}, nil
+}
+
+// parseFindPerm parses an octal MODE matched exactly, -MODE (all of these
+// bits set) or /MODE (any of them set).
+func parseFindPerm(val string) (findPred, error) {
+	kind, num := byte(0), val
+	if val != "" && (val[0] == '-' || val[0] == '/') {
+		kind, num = val[0], val[1:]
+	}
+	bits, err := strconv.ParseUint(num, 8, 32)
+	if err != nil || bits > 0o777 {
+		return nil, fmt.Errorf("-perm %s: want an octal mode like 644, -111 or /022", val)
+	}
+	mode := fs.FileMode(bits)
+	switch kind {
+		case '-':
+			return func(_ string, info fs.FileInfo) bool { return info.Mode().Perm()&mode == mode }, nil
+		case '/':
+			return func(_ string, info fs.FileInfo) bool { return info.Mode().Perm()&mode != 0 }, nil
+	}
+	return func(_ string, info fs.FileInfo) bool { return info.Mode().Perm() == mode }, nil
+}
+
+// parseFindOwner matches the owning user or group, given by name or numeric
+// id. Names resolve locally; use ids on remote panels.
+func parseFindOwner(group bool, val string) (findPred, error) {
+	id, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		var s string
+		if group {
+			var g *user.Group
+			if g, err = user.LookupGroup(val); err == nil {
+				s = g.Gid
+			}
+		} else {
+			var u *user.User
+			if u, err = user.Lookup(val); err == nil {
+				s = u.Uid
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if id, err = strconv.ParseUint(s, 10, 32); err != nil {
+			return nil, fmt.Errorf("%s has no numeric id", val)
+		}
+	}
+	want := uint32(id)
+	return func(_ string, info fs.FileInfo) bool {
+		uid, gid, ok := fileOwner(info)
+		if group {
+			return ok && gid == want
+		}
+		return ok && uid == want
+	}, nil
+}
+
+// fileOwner is the numeric owner of a local or sftp file.
+func fileOwner(info fs.FileInfo) (uid, gid uint32, ok bool) {
+	if st, ok := info.Sys().(*sftp.FileStat); ok {
+		return st.UID, st.GID, true
+	}
+	return sysOwner(info)
+}
+
+// findWalk walks root depth-first, collecting entries all preds accept.
+func findWalk(ctx context.Context, vfs vfsHandler, root string, preds []findPred, maxDepth int) ([]findEntry, bool, error) {
+	var found []findEntry
+	limited := false
+	var visit func(dir, rel string, depth int) error
+	visit = func(dir, rel string, depth int) error {
+		if ctx.Err() != nil || limited {
+			return nil
+		}
+		entries, err := vfs.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		var subdirs []string
+	entries:
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			if e.IsDir() && depth < maxDepth {
+				subdirs = append(subdirs, e.Name())
+			}
+			r := filepath.Join(rel, e.Name())
+			for _, pred := range preds {
+				if !pred(r, info) {
+					continue entries
+				}
+			}
+			if len(found) == findMaxResults {
+				limited = true
+				return nil
+			}
+			found = append(found, findEntry{rel: r, info: info})
+		}
+		// Unreadable subdirectories are skipped, like find does.
+		for _, name := range subdirs {
+			visit(filepath.Join(dir, name), filepath.Join(rel, name), depth+1)
+		}
+		return nil
+	}
+	err := visit(root, "", 1)
+	return found, limited, err
+}
+
+// startFind searches the active panel's directory, or DIR, in the
+// background; the results replace the panel's listing.
+func (m *Model) startFind(args []string) tea.Cmd {
+	p := &m.panels[m.activePanel]
+	dir, preds, maxDepth, err := parseFindArgs(args)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("find: %v – %s", err, findUsage))
+		return nil
+	}
+	root := p.currentDir
+	if dir != "" {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		if info, err := p.vfs.Stat(dir); err != nil || !info.IsDir() {
+			m.statusMsg = errorStyle.Render(fmt.Sprintf("find: %s is not a directory", dir))
+			return nil
+		}
+		root = filepath.Clean(dir)
+	}
+	if m.findCancel != nil {
+		m.findCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.findCancel = cancel
+	m.findGen++
+	gen, idx, from, vfs := m.findGen, m.activePanel, p.currentDir, p.vfs
+	query := strings.Join(args, " ")
+	m.statusMsg = warnStyle.Render(fmt.Sprintf("Finding %s…", query))
+	return func() tea.Msg {
+		entries, limited, err := findWalk(ctx, vfs, root, preds, maxDepth)
+		return findMsg{
+			gen:     gen,
+			idx:     idx,
+			from:    from,
+			listing: &findListing{query: query, vfs: vfs, root: root, entries: entries},
+			limited: limited,
+			err:     err,
+		}
+	}
+}
+
+// handleFindMsg turns the panel the find started from into the virtual
+// listing, unless it has moved on meanwhile.
+func (m *Model) handleFindMsg(msg findMsg) {
+	if msg.gen != m.findGen {
+		return
+	}
+	m.findCancel()
+	m.findCancel = nil
+	if msg.err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("find: %v", msg.err))
+		return
+	}
+	p := &m.panels[msg.idx]
+	if p.vfs != msg.listing.vfs || p.currentDir != msg.from {
+		m.statusMsg = warnStyle.Render("find: the panel changed directory, run it again")
+		return
+	}
+	if len(msg.listing.entries) == 0 {
+		m.statusMsg = warnStyle.Render(fmt.Sprintf("find %s: no matches", msg.listing.query))
+		return
+	}
+	if msg.listing.root != p.currentDir {
+		p.vfs.Chdir(msg.listing.root)
+		p.currentDir = msg.listing.root
+	}
+	p.find = msg.listing
+	p.selectedFiles = make(map[string]bool)
+	m.refreshPanel(msg.idx)
+	note := ""
+	if msg.limited {
+		note = fmt.Sprintf(" (stopped at %d)", findMaxResults)
+	}
+	m.statusMsg = successStyle.Render(fmt.Sprintf("%d matches%s – F5/F6/F8 and bulk rename work here, Backspace returns", len(msg.listing.entries), note))
+}
+
+// closeFind returns the active panel from find results to its real
+// directory, with the cursor on the top-level entry it was inside.
+func (m *Model) closeFind() {
+	p := &m.panels[m.activePanel]
+	name := ""
+	if sel, ok := p.fileList.SelectedItem().(item); ok {
+		name = strings.SplitN(filepath.ToSlash(sel.title), "/", 2)[0]
+	}
+	p.find = nil
+	m.refreshPanel(m.activePanel)
+	if name != "" {
+		m.selectName(m.activePanel, name)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package src
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// sysOwner is the numeric owner of a local file.
+func sysOwner(info fs.FileInfo) (uid, gid uint32, ok bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Uid, st.Gid, true
+	}
+	return 0, 0, false
+}
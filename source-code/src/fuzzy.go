@@ -0,0 +1,460 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// ─── Fuzzy finder ─────────────────────────────────────────────────────────────
+
+const (
+	fuzzyBatchSize  = 2048                  // entries per streamed batch
+	fuzzyBatchDelay = 50 * time.Millisecond // longest wait before a partial batch is sent
+	fuzzyMaxDepth   = 16
+	fuzzyMaxEntries = 200000
+	fuzzyWorkers    = 8 // concurrent ReadDir calls
+)
+
+// fuzzyEntry is one indexed path, relative to the finder's root.
+type fuzzyEntry struct {
+	rel     string
+	isDir   bool
+	modTime time.Time
+}
+
+// fuzzyResult is a match: an index into the finder's entries, its ranking
+// score and the matched byte offsets of rel.
+type fuzzyResult struct {
+	idx     int
+	score   int
+	matched []int
+}
+
+// fuzzyFinder indexes the active panel's tree (on any VFS) once per session and ranks
+// the index against the query on every keystroke.
+type fuzzyFinder struct {
+	vfs     vfsHandler
+	root    string
+	entries []fuzzyEntry
+	query   string
+	results []fuzzyResult
+	cursor  int
+	offset  int
+	height  int
+	walking bool
+	gen     int
+	batches chan []fuzzyEntry
+	cancel  context.CancelFunc
+	limited *atomic.Bool
+}
+
+// fuzzyBatchMsg carries newly indexed entries; done marks the end of the walk.
+type fuzzyBatchMsg struct {
+	gen     int
+	entries []fuzzyEntry
+	done    bool
+}
+
+// fuzzySource adapts a subset of entries to sahilm/fuzzy.
+type fuzzySource struct {
+	entries []fuzzyEntry
+	idx     []int
+}
+
+func (s fuzzySource) String(i int) string { return s.entries[s.idx[i]].rel }
+func (s fuzzySource) Len() int            { return len(s.idx) }
+
+// openFuzzy starts a finder session over the active panel's directory.
+func (m *Model) openFuzzy() tea.Cmd {
+	m.fuzzy.stop()
+	gen := m.fuzzy.gen + 1
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &m.panels[m.activePanel]
+	m.fuzzy = fuzzyFinder{
+		vfs:     p.vfs,
+		root:    p.currentDir,
+		height:  m.fuzzy.height,
+		walking: true,
+		gen:     gen,
+		batches: make(chan []fuzzyEntry, 4),
+		cancel:  cancel,
+		limited: new(atomic.Bool),
+	}
+	m.mode = fuzzyMode
+	m.fuzzyInput.Reset()
+	m.fuzzyInput.Focus()
+	go m.indexer.walkTree(ctx, p.vfs, p.currentDir, nil, m.fuzzy.batches, m.fuzzy.limited)
+	return m.fuzzy.waitCmd()
+}
+
+func (f *fuzzyFinder) stop() {
+	if f.cancel != nil {
+		f.cancel()
+		f.cancel = nil
+	}
+	f.walking = false
+}
+
+// fuzzyWalk indexes root through vfs, reading up to fuzzyWorkers
+// directories at once to hide sftp and podman round trips. Entries are
+// batched into out, which is closed when the walk ends or ctx is cancelled;
+// limited is set when the depth or entry limit cut the walk short.
+func fuzzyWalk(ctx context.Context, vfs vfsHandler, root string, out chan<- []fuzzyEntry, limited *atomic.Bool) {
+	defer close(out)
+	found := make(chan []fuzzyEntry, fuzzyWorkers)
+	sem := make(chan struct{}, fuzzyWorkers)
+	var wg sync.WaitGroup
+	var count atomic.Int64
+	var visit func(dir, rel string, depth int, rules ignoreRules)
+	visit = func(dir, rel string, depth int, rules ignoreRules) {
+		defer wg.Done()
+		select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+		}
+		entries, err := vfs.ReadDir(dir)
+		<-sem
+		if err != nil || ctx.Err() != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.Name() == ".gitignore" && !e.IsDir() {
+				rules = loadGitignore(vfs, dir, filepath.ToSlash(rel), rules)
+				break
+			}
+		}
+		var batch []fuzzyEntry
+		for _, e := range entries {
+			r := filepath.Join(rel, e.Name())
+			if e.Name() == ".git" || rules.ignored(filepath.ToSlash(r), e.IsDir()) {
+				continue
+			}
+			if count.Add(1) > fuzzyMaxEntries {
+				limited.Store(true)
+				break
+			}
+			fe := fuzzyEntry{rel: r, isDir: e.IsDir()}
+			if info, err := e.Info(); err == nil {
+				fe.modTime = info.ModTime()
+			}
+			batch = append(batch, fe)
+			if !e.IsDir() {
+				continue
+			}
+			if depth >= fuzzyMaxDepth {
+				limited.Store(true)
+				continue
+			}
+			wg.Add(1)
+			go visit(filepath.Join(dir, e.Name()), r, depth+1, rules)
+		}
+		select {
+			case found <- batch:
+			case <-ctx.Done():
+		}
+	}
+	wg.Add(1)
+	go visit(root, "", 1, nil)
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	// Directories arrive one at a time; pass them on in useful batches.
+	var batch []fuzzyEntry
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+		}
+	}
+	tick := time.NewTicker(fuzzyBatchDelay)
+	defer tick.Stop()
+	for {
+		select {
+			case b, ok := <-found:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, b...)
+				if len(batch) >= fuzzyBatchSize && !flush() {
+					return
+				}
+			case <-tick.C:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+		}
+	}
+}
+
+// waitCmd delivers the next batch of the running walk.
+func (f *fuzzyFinder) waitCmd() tea.Cmd {
+	gen, ch := f.gen, f.batches
+	return func() tea.Msg {
+		batch, ok := <-ch
+		return fuzzyBatchMsg{gen: gen, entries: batch, done: !ok}
+	}
+}
+
+// handleFuzzyBatch adds streamed entries to the index and ranks only them,
+// so the list grows without re-matching what is already there.
+func (m *Model) handleFuzzyBatch(msg fuzzyBatchMsg) tea.Cmd {
+	f := &m.fuzzy
+	if msg.gen != f.gen || !f.walking {
+		return nil
+	}
+	if msg.done {
+		f.walking = false
+		f.cancel = nil
+		return nil
+	}
+	start := len(f.entries)
+	f.entries = append(f.entries, msg.entries...)
+	if f.query != "" {
+		idx := make([]int, len(msg.entries))
+		for i := range idx {
+			idx[i] = start + i
+		}
+		f.results = append(f.results, f.match(idx)...)
+		f.sortResults()
+	}
+	return f.waitCmd()
+}
+
+// setQuery re-ranks the index for query. A query that extends the previous
+// one only needs to look at the previous results.
+func (f *fuzzyFinder) setQuery(query string) {
+	if query == f.query {
+		return
+	}
+	var idx []int
+	if f.query != "" && strings.HasPrefix(query, f.query) {
+		idx = make([]int, len(f.results))
+		for i, r := range f.results {
+			idx[i] = r.idx
+		}
+	} else {
+		idx = make([]int, len(f.entries))
+		for i := range idx {
+			idx[i] = i
+		}
+	}
+	f.query = query
+	f.results = nil
+	f.cursor, f.offset = 0, 0
+	if query == "" {
+		return
+	}
+	f.results = f.match(idx)
+	f.sortResults()
+}
+
+// match scores the candidates; on top of sahilm/fuzzy's boundary and
+// adjacency scoring it favours matches in the file name, shallow paths and
+// recently modified files.
+func (f *fuzzyFinder) match(idx []int) []fuzzyResult {
+	matches := fuzzy.FindFromNoSort(f.query, fuzzySource{f.entries, idx})
+	now := time.Now()
+	results := make([]fuzzyResult, len(matches))
+	for i, mt := range matches {
+		e := f.entries[idx[mt.Index]]
+		score := mt.Score
+		base := strings.LastIndexByte(e.rel, filepath.Separator) + 1
+		inBase := 0
+		for _, b := range mt.MatchedIndexes {
+			if b >= base {
+				inBase++
+			}
+		}
+		score += 3 * inBase
+		if inBase == len(mt.MatchedIndexes) {
+			score += 15
+		}
+		score -= 2 * strings.Count(e.rel, string(filepath.Separator))
+		switch age := now.Sub(e.modTime); {
+			case age < 24*time.Hour:
+				score += 10
+			case age < 7*24*time.Hour:
+				score += 5
+		}
+		results[i] = fuzzyResult{idx: idx[mt.Index], score: score, matched: append([]int(nil), mt.MatchedIndexes...)}
+	}
+	return results
+}
+
+func (f *fuzzyFinder) sortResults() {
+	sort.SliceStable(f.results, func(i, j int) bool {
+		a, b := f.results[i], f.results[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		ra, rb := f.entries[a.idx].rel, f.entries[b.idx].rel
+		if len(ra) != len(rb) {
+			return len(ra) < len(rb)
+		}
+		return ra < rb
+	})
+}
+
+// selected returns the entry under the cursor.
+func (f *fuzzyFinder) selected() (fuzzyEntry, bool) {
+	if f.cursor < 0 || f.cursor >= len(f.results) {
+		return fuzzyEntry{}, false
+	}
+	return f.entries[f.results[f.cursor].idx], true
+}
+
+func (f *fuzzyFinder) move(delta int) {
+	f.cursor = max(min(f.cursor+delta, len(f.results)-1), 0)
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	}
+	if f.cursor >= f.offset+f.height {
+		f.offset = f.cursor - f.height + 1
+	}
+}
+
+// ─── Result actions ───────────────────────────────────────────────────────────
+
+// fuzzyOpen leaves the finder for the result under the cursor: directories
+// are entered, files are shown in their directory with the cursor on them,
+// or opened in the editor when edit is set.
+func (m *Model) fuzzyOpen(edit bool) tea.Cmd {
+	f := &m.fuzzy
+	e, ok := f.selected()
+	if !ok {
+		return nil
+	}
+	full := filepath.Join(f.root, e.rel)
+	f.stop()
+	m.mode = explorerMode
+	dir := full
+	if !e.isDir {
+		dir = filepath.Dir(full)
+	}
+	if err := m.changeDir(m.activePanel, dir); err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("cd: %v", err))
+		return nil
+	}
+	if e.isDir {
+		return nil
+	}
+	m.selectName(m.activePanel, filepath.Base(full))
+	if edit {
+		return m.openEditor(full)
+	}
+	return nil
+}
+
+// fuzzyToggleSelect adds the result under the cursor to the panel's
+// selection, which may span directories, and moves down.
+func (m *Model) fuzzyToggleSelect() {
+	f := &m.fuzzy
+	e, ok := f.selected()
+	if !ok {
+		return
+	}
+	p := &m.panels[m.activePanel]
+	full := filepath.Join(f.root, e.rel)
+	p.selectedFiles[full] = !p.selectedFiles[full]
+	if !p.selectedFiles[full] {
+		delete(p.selectedFiles, full)
+	}
+	m.syncSelectionToList(m.activePanel)
+	f.move(1)
+}
+
+// ─── Fuzzy view ───────────────────────────────────────────────────────────────
+
+// highlightMatches renders s with the bytes at matched (ascending offsets
+// of rune starts) emphasised.
+func highlightMatches(s string, matched []int, base lipgloss.Style) string {
+	var sb strings.Builder
+	prev, k := 0, 0
+	for i, r := range s {
+		if k < len(matched) && matched[k] == i {
+			sb.WriteString(base.Render(s[prev:i]))
+			sb.WriteString(fuzzyMatchStyle.Render(string(r)))
+			prev = i + len(string(r))
+			k++
+		}
+	}
+	sb.WriteString(base.Render(s[prev:]))
+	return sb.String()
+}
+
+func (f *fuzzyFinder) view(width int, selected map[string]bool) string {
+	if len(f.results) == 0 {
+		msg := "Type to search"
+		if f.query != "" {
+			msg = "No matches"
+		}
+		if f.walking {
+			msg += fmt.Sprintf(" (indexing… %d entries)", len(f.entries))
+		}
+		return metaKeyStyle.Render(msg)
+	}
+	var sb strings.Builder
+	end := min(f.offset+f.height, len(f.results))
+	for i := f.offset; i < end; i++ {
+		r := f.results[i]
+		e := f.entries[r.idx]
+		style, name := fileStyle, e.rel
+		if e.isDir {
+			style, name = dirStyle, e.rel+"/"
+		}
+		cur, sel := " ", " "
+		if i == f.cursor {
+			cur = dirStyle.Render(selectedMarker)
+		}
+		if selected[filepath.Join(f.root, e.rel)] {
+			sel = gitAddedStyle.Render("✓")
+		}
+		if i > f.offset {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(fitCell(cur+sel+highlightMatches(name, r.matched, style), width))
+	}
+	return sb.String()
+}
+
+func (f *fuzzyFinder) statusLine(selected map[string]bool) string {
+	s := fmt.Sprintf("%d results  •  %d indexed", len(f.results), len(f.entries))
+	n := 0
+	for _, v := range selected {
+		if v {
+			n++
+		}
+	}
+	if n > 0 {
+		s += fmt.Sprintf("  •  %d selected", n)
+	}
+	switch {
+		case f.walking:
+			s += " (indexing…)"
+		case f.limited != nil && f.limited.Load():
+			s += fmt.Sprintf(" (limit reached: depth %d, %d entries)", fuzzyMaxDepth, fuzzyMaxEntries)
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package src
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ─── .gitignore ───────────────────────────────────────────────────────────────
+
+// ignoreRule is one .gitignore pattern, compiled against paths relative to
+// the directory holding the file.
+type ignoreRule struct {
+	base    string // slash-separated directory of the .gitignore, relative to the walk root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules is the stack of rules in effect for a directory; later rules
+// win, as in git.
+type ignoreRules []ignoreRule
+
+// parseGitignore reads a .gitignore located at base (relative to the walk
+// root) and returns rules extending parent.
+func parseGitignore(r io.Reader, base string, parent ignoreRules) ignoreRules {
+	rules := parent
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		line = strings.TrimPrefix(line, "\\")
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		// A slash anywhere but the end anchors the pattern to base.
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		// Copy on first append so sibling directories don't share a tail.
+		if len(rules) == len(parent) {
+			rules = append(ignoreRules(nil), parent...)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globToRegexp translates gitignore glob syntax, including **.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				sb.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+				sb.WriteString("/.*")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				sb.WriteString(".*")
+				i++
+			case c == '*':
+				sb.WriteString("[^/]*")
+			case c == '?':
+				sb.WriteString("[^/]")
+			case c == '[':
+				end := strings.IndexByte(glob[i+1:], ']')
+				if end < 0 {
+					sb.WriteString(`\[`)
+					continue
+				}
+				class := glob[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += end + 1
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// ignored reports whether rel (slash-separated, relative to the walk root)
+// is excluded.
+func (rules ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+		if r.re.MatchString(sub) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// loadGitignore extends rules with the .gitignore in dir.
+func loadGitignore(vfs vfsHandler, dir, rel string, rules ignoreRules) ignoreRules {
+	f, err := vfs.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return rules
+	}
+	defer f.Close()
+	return parseGitignore(f, rel, rules)
+}
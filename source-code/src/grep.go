@@ -0,0 +1,515 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Content search ───────────────────────────────────────────────────────────
+
+const (
+	grepWorkers    = 8
+	grepMaxMatches = 10000
+	grepMaxLineLen = 400 // longer lines are cut in the results list
+)
+
+// grepOptions is a parsed grep command line.
+type grepOptions struct {
+	pattern    string
+	literal    bool
+	ignoreCase bool
+	include    []string // globs a file must match (any of)
+	exclude    []string // globs that drop a file
+	context    int
+}
+
+// parseGrepArgs reads `grep [-F] [-i] [-g GLOB]... [-C N] PATTERN`; a glob
+// starting with ! excludes.
+func parseGrepArgs(args []string) (grepOptions, error) {
+	opts := grepOptions{context: 1}
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && len(args[0]) > 1 {
+		switch args[0] {
+			case "-F":
+				opts.literal = true
+			case "-i":
+				opts.ignoreCase = true
+			case "-g", "-C":
+				if len(args) < 2 {
+					return opts, fmt.Errorf("%s needs a value", args[0])
+				}
+				if args[0] == "-C" {
+					n, err := strconv.Atoi(args[1])
+					if err != nil || n < 0 {
+						return opts, fmt.Errorf("bad context %q", args[1])
+					}
+					opts.context = n
+				} else if g, ok := strings.CutPrefix(args[1], "!"); ok {
+					opts.exclude = append(opts.exclude, g)
+				} else {
+					opts.include = append(opts.include, args[1])
+				}
+				args = args[1:]
+			case "--":
+				args = args[1:]
+				goto done
+			default:
+				return opts, fmt.Errorf("unknown flag %s", args[0])
+		}
+		args = args[1:]
+	}
+done:
+	opts.pattern = strings.Join(args, " ")
+	if opts.pattern == "" {
+		return opts, fmt.Errorf("usage: grep [-F] [-i] [-g GLOB] [-C N] PATTERN")
+	}
+	return opts, nil
+}
+
+func (o grepOptions) compile() (*regexp.Regexp, error) {
+	expr := o.pattern
+	if o.literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if o.ignoreCase {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// wants reports whether the file at rel passes the glob filters. Globs
+// without a slash match the file name, others the relative path.
+func (o grepOptions) wants(rel string) bool {
+	matches := func(glob string) bool {
+		target := filepath.Base(rel)
+		if strings.Contains(glob, "/") {
+			target = filepath.ToSlash(rel)
+		}
+		ok, _ := filepath.Match(glob, target)
+		return ok
+	}
+	for _, g := range o.exclude {
+		if matches(g) {
+			return false
+		}
+	}
+	if len(o.include) == 0 {
+		return true
+	}
+	for _, g := range o.include {
+		if matches(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// grepMatch is one matching line with its surrounding context; spans are
+// byte ranges of text.
+type grepMatch struct {
+	rel    string
+	line   int // 0-based
+	text   string
+	spans  [][]int
+	before []string
+	after  []string
+}
+
+// grepSearch is a running or finished search and its results list.
+type grepSearch struct {
+	vfs     vfsHandler
+	index   *indexer
+	root    string
+	opts    grepOptions
+	re      *regexp.Regexp
+	matches []grepMatch
+	rows    []string      // matches laid out for display, built as batches arrive
+	at      []int         // row of every match
+	files   *atomic.Int64 // files searched so far
+	limited *atomic.Bool
+	running bool
+	cursor  int
+	offset  int // first visible row
+	width   int
+	height  int
+	gen     int
+	results chan []grepMatch
+	cancel  context.CancelFunc
+}
+
+type grepBatchMsg struct {
+	gen     int
+	matches []grepMatch
+	done    bool
+}
+
+// startGrep searches the active panel's tree and opens the results list.
+func (m *Model) startGrep(args []string) tea.Cmd {
+	opts, err := parseGrepArgs(args)
+	if err != nil {
+		m.statusMsg = errorStyle.Render("grep: " + err.Error())
+		return nil
+	}
+	re, err := opts.compile()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("grep: " + err.Error())
+		return nil
+	}
+	m.grep.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &m.panels[m.activePanel]
+	w, h := m.editorSize()
+	m.grep = grepSearch{
+		vfs:     p.vfs,
+		index:   m.indexer,
+		root:    p.currentDir,
+		opts:    opts,
+		re:      re,
+		files:   new(atomic.Int64),
+		limited: new(atomic.Bool),
+		running: true,
+		width:   w,
+		height:  h,
+		gen:     m.grep.gen + 1,
+		results: make(chan []grepMatch, grepWorkers),
+		cancel:  cancel,
+	}
+	m.statusMsg = ""
+	m.commandInput.Blur()
+	m.mode = grepMode
+	go m.grep.run(ctx)
+	return m.grep.waitCmd()
+}
+
+func (g *grepSearch) stop() {
+	if g.cancel != nil {
+		g.cancel()
+		g.cancel = nil
+	}
+	g.running = false
+}
+
+// run feeds the files found by fuzzyWalk or the index (so .gitignore and
+// the walk limits apply) to a pool of searchers and batches what they find.
+func (g *grepSearch) run(ctx context.Context) {
+	defer close(g.results)
+	entries := make(chan []fuzzyEntry, 4)
+	go g.index.walkTree(ctx, g.vfs, g.root, g.re, entries, g.limited)
+
+	files := make(chan string, grepWorkers*4)
+	found := make(chan []grepMatch, grepWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < grepWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range files {
+				if ms := g.searchFile(ctx, rel); len(ms) > 0 {
+					select {
+						case found <- ms:
+						case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(files)
+		for batch := range entries {
+			for _, e := range batch {
+				if e.isDir || !g.opts.wants(e.rel) {
+					continue
+				}
+				select {
+					case files <- e.rel:
+					case <-ctx.Done():
+						return
+				}
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	var batch []grepMatch
+	total := 0
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		select {
+			case g.results <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+		}
+	}
+	tick := time.NewTicker(fuzzyBatchDelay)
+	defer tick.Stop()
+	for {
+		select {
+			case ms, ok := <-found:
+				if !ok {
+					flush()
+					return
+				}
+				if total+len(ms) > grepMaxMatches {
+					ms = ms[:grepMaxMatches-total]
+					g.limited.Store(true)
+				}
+				batch = append(batch, ms...)
+				if total += len(ms); total >= grepMaxMatches {
+					flush()
+					return
+				}
+			case <-tick.C:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+		}
+	}
+}
+
+// searchFile returns the matches in one file; binaries and files too large
+// to edit are skipped.
+func (g *grepSearch) searchFile(ctx context.Context, rel string) []grepMatch {
+	if ctx.Err() != nil {
+		return nil
+	}
+	f, err := g.vfs.Open(filepath.Join(g.root, rel))
+	if err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(ctxReader{ctx, f}, maxFileSizeForEdit+1))
+	f.Close()
+	g.files.Add(1)
+	if err != nil || len(data) > maxFileSizeForEdit {
+		return nil
+	}
+	format := detectFormat(data)
+	if !format.isUTF16() && looksBinary(data) {
+		return nil
+	}
+	text, err := decodeText(data, format)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	display := func(s string) string {
+		s = strings.ReplaceAll(s, "\t", "    ")
+		if len(s) > grepMaxLineLen {
+			s = truncateRunes(s, grepMaxLineLen)
+		}
+		return s
+	}
+	var ms []grepMatch
+	for i, line := range lines {
+		if !g.re.MatchString(line) {
+			continue
+		}
+		text := display(line)
+		m := grepMatch{rel: rel, line: i, text: text, spans: g.re.FindAllStringIndex(text, -1)}
+		for j := max(i-g.opts.context, 0); j < i; j++ {
+			m.before = append(m.before, display(lines[j]))
+		}
+		for j := i + 1; j <= min(i+g.opts.context, len(lines)-1); j++ {
+			m.after = append(m.after, display(lines[j]))
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func (g *grepSearch) waitCmd() tea.Cmd {
+	gen, ch := g.gen, g.results
+	return func() tea.Msg {
+		batch, ok := <-ch
+		return grepBatchMsg{gen: gen, matches: batch, done: !ok}
+	}
+}
+
+func (m *Model) handleGrepBatch(msg grepBatchMsg) tea.Cmd {
+	g := &m.grep
+	if msg.gen != g.gen || !g.running {
+		return nil
+	}
+	if msg.done {
+		// Stopping at grepMaxMatches leaves workers blocked; release them.
+		g.stop()
+		return nil
+	}
+	g.add(msg.matches)
+	return g.waitCmd()
+}
+
+// ─── Results list ─────────────────────────────────────────────────────────────
+
+// openGrepMatch opens the match under the cursor in the editor at its line.
+func (m *Model) openGrepMatch() tea.Cmd {
+	g := &m.grep
+	if g.cursor >= len(g.matches) {
+		return nil
+	}
+	match := g.matches[g.cursor]
+	file := filepath.Join(g.root, match.rel)
+	if m.panels[m.activePanel].vfs != g.vfs {
+		m.statusMsg = errorStyle.Render("grep: the panel has left the searched filesystem")
+		return nil
+	}
+	m.commandInput.Focus()
+	cmd := m.openEditor(file)
+	if b := m.currentBuffer(); m.mode == editorMode && b != nil && b.file == file {
+		b.gotoLine(match.line)
+	}
+	return cmd
+}
+
+func (m *Model) closeGrep() {
+	m.grep.stop()
+	m.mode = explorerMode
+	m.commandInput.Focus()
+}
+
+func (m *Model) updateGrep(msg tea.KeyMsg) tea.Cmd {
+	g := &m.grep
+	switch {
+		case key.Matches(msg, m.keys.cancel), msg.String() == "q":
+			m.closeGrep()
+			return nil
+		case key.Matches(msg, m.keys.execute):
+			return m.openGrepMatch()
+		case key.Matches(msg, m.keys.down):
+			g.cursor++
+		case key.Matches(msg, m.keys.up):
+			g.cursor--
+	}
+	switch msg.String() {
+		case "pgdown", " ":
+			g.cursor += max(g.height/(2+2*g.opts.context), 1)
+		case "pgup", "b":
+			g.cursor -= max(g.height/(2+2*g.opts.context), 1)
+		case "g", "home":
+			g.cursor = 0
+		case "G", "end":
+			g.cursor = len(g.matches) - 1
+	}
+	g.cursor = max(min(g.cursor, len(g.matches)-1), 0)
+	g.scroll()
+	return nil
+}
+
+// scroll keeps the cursor's match and its context on screen.
+func (g *grepSearch) scroll() {
+	if len(g.matches) == 0 {
+		return
+	}
+	cur := g.at[g.cursor]
+	if cur-g.opts.context-1 < g.offset {
+		g.offset = cur - g.opts.context - 1
+	}
+	if cur+g.opts.context >= g.offset+g.height {
+		g.offset = cur + g.opts.context - g.height + 1
+	}
+	g.offset = max(min(g.offset, len(g.rows)-g.height), 0)
+}
+
+// add appends a batch of matches and lays out their rows: a header per
+// file, then each match with its context. Rendering once here keeps
+// scrolling through thousands of matches cheap.
+func (g *grepSearch) add(batch []grepMatch) {
+	for _, mt := range batch {
+		if n := len(g.matches); n == 0 || g.matches[n-1].rel != mt.rel {
+			g.rows = append(g.rows, dirStyle.Render(mt.rel))
+		}
+		g.matches = append(g.matches, mt)
+		for k, s := range mt.before {
+			g.rows = append(g.rows, metaKeyStyle.Render(fmt.Sprintf("%6d- ", mt.line-len(mt.before)+k+1))+s)
+		}
+		g.at = append(g.at, len(g.rows))
+		var sb strings.Builder
+		prev := 0
+		for _, sp := range mt.spans {
+			sb.WriteString(mt.text[prev:sp[0]])
+			sb.WriteString(pagerMatchStyle.Render(mt.text[sp[0]:sp[1]]))
+			prev = sp[1]
+		}
+		sb.WriteString(mt.text[prev:])
+		g.rows = append(g.rows, gitAddedStyle.Render(fmt.Sprintf("%6d: ", mt.line+1))+sb.String())
+		for k, s := range mt.after {
+			g.rows = append(g.rows, metaKeyStyle.Render(fmt.Sprintf("%6d- ", mt.line+k+2))+s)
+		}
+	}
+}
+
+func (g *grepSearch) view() string {
+	if len(g.matches) == 0 {
+		if g.running {
+			return metaKeyStyle.Render("Searching…")
+		}
+		return metaKeyStyle.Render("No matches")
+	}
+	cur := g.at[g.cursor]
+	end := min(g.offset+g.height, len(g.rows))
+	var sb strings.Builder
+	for i := g.offset; i < end; i++ {
+		if i > g.offset {
+			sb.WriteByte('\n')
+		}
+		prefix := "  "
+		if i == cur {
+			prefix = dirStyle.Render(selectedMarker + " ")
+		}
+		sb.WriteString(fitCell(prefix+g.rows[i], g.width))
+	}
+	return sb.String()
+}
+
+func (g *grepSearch) title() string {
+	flags := ""
+	if g.opts.literal {
+		flags += " -F"
+	}
+	if g.opts.ignoreCase {
+		flags += " -i"
+	}
+	for _, gl := range g.opts.include {
+		flags += " -g " + gl
+	}
+	for _, gl := range g.opts.exclude {
+		flags += " -g !" + gl
+	}
+	return fmt.Sprintf("%q%s in %s%s", g.opts.pattern, flags, g.vfs.VFSName(), g.root)
+}
+
+func (g *grepSearch) statusLine() string {
+	files := map[string]bool{}
+	for _, mt := range g.matches {
+		files[mt.rel] = true
+	}
+	s := fmt.Sprintf("%d matches in %d files  •  %d files searched", len(g.matches), len(files), g.files.Load())
+	switch {
+		case g.running:
+			s += " (searching…)"
+		case g.limited.Load():
+			s += " (limit reached)"
+	}
+	if len(g.matches) > 0 {
+		s += fmt.Sprintf("  │  %d/%d", g.cursor+1, len(g.matches))
+	}
+	return s
+}
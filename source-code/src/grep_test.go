@@ -0,0 +1,77 @@
+package src
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGrepArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    grepOptions
+		wantErr bool
+	}{
+		{args: []string{"TODO"}, want: grepOptions{pattern: "TODO", context: 1}},
+		{args: []string{"two", "words"}, want: grepOptions{pattern: "two words", context: 1}},
+		{args: []string{"-F", "-i", "a.b"}, want: grepOptions{pattern: "a.b", literal: true, ignoreCase: true, context: 1}},
+		{args: []string{"-C", "3", "x"}, want: grepOptions{pattern: "x", context: 3}},
+		{args: []string{"-C", "0", "x"}, want: grepOptions{pattern: "x"}},
+		{
+			args: []string{"-g", "*.go", "-g", "!*_test.go", "-g", "cmd/*", "func"},
+			want: grepOptions{pattern: "func", include: []string{"*.go", "cmd/*"}, exclude: []string{"*_test.go"}, context: 1},
+		},
+		{args: []string{"--", "-i"}, want: grepOptions{pattern: "-i", context: 1}},
+		{args: []string{"-"}, want: grepOptions{pattern: "-", context: 1}},
+		{args: []string{"-C", "-1", "x"}, wantErr: true},
+		{args: []string{"-C", "many", "x"}, wantErr: true},
+		{args: []string{"x", "-g"}, want: grepOptions{pattern: "x -g", context: 1}},
+		{args: []string{"-g"}, wantErr: true},
+		{args: []string{"-x", "y"}, wantErr: true},
+		{args: []string{"-i"}, wantErr: true},
+		{args: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseGrepArgs(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseGrepArgs(%q) = %+v, want an error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseGrepArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGrepArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestGrepOptionsWants(t *testing.T) {
+	tests := []struct {
+		include, exclude []string
+		rel              string
+		want             bool
+	}{
+		{nil, nil, "any/file.txt", true},
+		{[]string{"*.go"}, nil, "main.go", true},
+		{[]string{"*.go"}, nil, "src/deep/model.go", true},
+		{[]string{"*.go"}, nil, "README.md", false},
+		{[]string{"*.go", "*.md"}, nil, "README.md", true},
+		{nil, []string{"*_test.go"}, "src/sync_test.go", false},
+		{nil, []string{"*_test.go"}, "src/sync.go", true},
+		{[]string{"*.go"}, []string{"*_test.go"}, "src/sync_test.go", false},
+		{[]string{"src/*"}, nil, "src/grep.go", true},
+		{[]string{"src/*"}, nil, "src/sub/grep.go", false},
+		{[]string{"src/*"}, nil, "other/src/grep.go", false},
+		{nil, []string{"vendor/*"}, "vendor/lib.go", false},
+		{nil, []string{"vendor/*"}, "lib.go", true},
+	}
+	for _, tt := range tests {
+		o := grepOptions{include: tt.include, exclude: tt.exclude}
+		if got := o.wants(tt.rel); got != tt.want {
+			t.Errorf("include %q exclude %q: wants(%q) = %v, want %v", tt.include, tt.exclude, tt.rel, got, tt.want)
+		}
+	}
+}
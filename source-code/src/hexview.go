@@ -0,0 +1,441 @@
+package src
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Hex dump ─────────────────────────────────────────────────────────────────
+
+// hexBytesPerRow picks 16 or 8 bytes per row depending on available width.
+func hexBytesPerRow(width int) int {
+	if width > 0 && width < 78 {
+		return 8
+	}
+	return 16
+}
+
+// hexDump renders data as offset │ hex bytes │ ASCII rows starting at base.
+func hexDump(data []byte, base int64, perRow int) string {
+	var sb strings.Builder
+	for off := 0; off < len(data); off += perRow {
+		end := off + perRow
+		if end > len(data) {
+			end = len(data)
+		}
+		sb.WriteString(hexRow(data[off:end], base+int64(off), perRow, -1, nil))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// hexRow formats a single row. cursor is the index within row to highlight
+// (or -1) and modified reports which row indexes were changed.
+func hexRow(row []byte, offset int64, perRow, cursor int, modified func(int) bool) string {
+	var hexPart, asciiPart strings.Builder
+	for i := 0; i < perRow; i++ {
+		if i == perRow/2 {
+			hexPart.WriteByte(' ')
+		}
+		if i >= len(row) {
+			hexPart.WriteString("   ")
+			continue
+		}
+		h := fmt.Sprintf("%02x", row[i])
+		a := string(printableByte(row[i]))
+		switch {
+			case i == cursor:
+				h = hexCursorStyle.Render(h)
+				a = hexCursorStyle.Render(a)
+			case modified != nil && modified(i):
+				h = hexModifiedStyle.Render(h)
+				a = hexModifiedStyle.Render(a)
+			case row[i] == 0:
+				h = hexZeroStyle.Render(h)
+		}
+		hexPart.WriteString(h + " ")
+		asciiPart.WriteString(a)
+	}
+	return hexOffsetStyle.Render(fmt.Sprintf("%08x", offset)) + "  " + hexPart.String() + "│" + asciiPart.String() + "│"
+}
+
+func printableByte(b byte) byte {
+	if b >= 0x20 && b < 0x7f {
+		return b
+	}
+	return '.'
+}
+
+// looksBinary applies git's heuristic: a NUL in the first 8000 bytes.
+func looksBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// parseBytePattern accepts either hex pairs ("de ad be ef", "0xdeadbeef")
+// or a quoted string ("\"PK\"") and returns the raw bytes to search for.
+func parseBytePattern(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return []byte(s[1 : len(s)-1]), nil
+	}
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	s = strings.NewReplacer(" ", "", ":", "", "-", "").Replace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	return hex.DecodeString(s)
+}
+
+// ─── Hex editor ───────────────────────────────────────────────────────────────
+
+type hexPrompt int
+
+const (
+	hexPromptNone hexPrompt = iota
+	hexPromptGoto
+	hexPromptSearch
+)
+
+// hexEditor overwrites bytes in place; the file length never changes.
+type hexEditor struct {
+	file     string
+	vfs      vfsHandler
+	data     []byte
+	modified map[int64]bool
+	cursor   int64
+	top      int64 // first visible row
+	lowNib   bool  // next hex digit goes to the low nibble
+	ascii    bool  // typing goes to the ASCII column
+	width    int
+	height   int
+
+	prompt  hexPrompt
+	input   textinput.Model
+	pattern []byte
+}
+
+func (m *Model) openHexEditor(file string) {
+	p := &m.panels[m.activePanel]
+	stat, err := p.vfs.Stat(file)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("hexedit: %v", err))
+		return
+	}
+	if stat.Size() > maxFileSizeForEdit {
+		m.statusMsg = errorStyle.Render("File too large to edit (>10MB)")
+		return
+	}
+	f, err := p.vfs.Open(file)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("hexedit: %v", err))
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("hexedit: %v", err))
+		return
+	}
+	m.startHexEditor(p.vfs, file, data)
+}
+
+func (m *Model) startHexEditor(vfs vfsHandler, file string, data []byte) {
+	ti := textinput.New()
+	ti.Width = 40
+	w, h := m.editorSize()
+	m.hex = hexEditor{
+		file:     file,
+		vfs:      vfs,
+		data:     data,
+		modified: make(map[int64]bool),
+		width:    w,
+		height:   h,
+		input:    ti,
+	}
+	m.commandInput.Blur()
+	m.mode = hexMode
+}
+
+func (h *hexEditor) perRow() int { return hexBytesPerRow(h.width) }
+
+func (h *hexEditor) dirty() bool { return len(h.modified) > 0 }
+
+// moveTo places the cursor at off and scrolls it into view.
+func (h *hexEditor) moveTo(off int64) {
+	if len(h.data) == 0 {
+		h.cursor = 0
+		return
+	}
+	if off < 0 {
+		off = 0
+	}
+	if off >= int64(len(h.data)) {
+		off = int64(len(h.data)) - 1
+	}
+	h.cursor = off
+	h.lowNib = false
+	per := int64(h.perRow())
+	row := off / per
+	rows := int64(h.height)
+	if rows < 1 {
+		rows = 1
+	}
+	if row < h.top {
+		h.top = row
+	} else if row >= h.top+rows {
+		h.top = row - rows + 1
+	}
+}
+
+// typeHex writes one hex digit into the current nibble.
+func (h *hexEditor) typeHex(r rune) bool {
+	v, err := strconv.ParseUint(string(r), 16, 8)
+	if err != nil || len(h.data) == 0 {
+		return false
+	}
+	b := h.data[h.cursor]
+	if h.lowNib {
+		b = b&0xf0 | byte(v)
+	} else {
+		b = b&0x0f | byte(v)<<4
+	}
+	h.setByte(b)
+	if h.lowNib {
+		h.moveTo(h.cursor + 1)
+	} else {
+		h.lowNib = true
+	}
+	return true
+}
+
+func (h *hexEditor) typeASCII(r rune) bool {
+	if r < 0x20 || r >= 0x7f || len(h.data) == 0 {
+		return false
+	}
+	h.setByte(byte(r))
+	h.moveTo(h.cursor + 1)
+	return true
+}
+
+func (h *hexEditor) setByte(b byte) {
+	if h.data[h.cursor] != b {
+		h.data[h.cursor] = b
+		h.modified[h.cursor] = true
+	}
+}
+
+// search finds the next occurrence of pattern after the cursor, wrapping.
+func (h *hexEditor) search(pattern []byte, from int64) (int64, bool) {
+	if len(pattern) == 0 || len(h.data) == 0 {
+		return 0, false
+	}
+	if from < int64(len(h.data)) {
+		if i := bytes.Index(h.data[from:], pattern); i >= 0 {
+			return from + int64(i), true
+		}
+	}
+	if i := bytes.Index(h.data, pattern); i >= 0 {
+		return int64(i), true
+	}
+	return 0, false
+}
+
+func (h *hexEditor) save() error {
+	w, err := h.vfs.Create(h.file)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(h.data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	h.modified = make(map[int64]bool)
+	return nil
+}
+
+// parseOffset accepts decimal, 0x-prefixed hex, or a percentage ("50%").
+func parseOffset(s string, size int64) (int64, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return int64(float64(size) * pct / 100), nil
+	}
+	return strconv.ParseInt(s, 0, 64)
+}
+
+func (h *hexEditor) view() string {
+	per := h.perRow()
+	var rows []string
+	for r := int64(0); r < int64(h.height); r++ {
+		start := (h.top + r) * int64(per)
+		if start >= int64(len(h.data)) {
+			break
+		}
+		end := start + int64(per)
+		if end > int64(len(h.data)) {
+			end = int64(len(h.data))
+		}
+		cursor := -1
+		if h.cursor >= start && h.cursor < end {
+			cursor = int(h.cursor - start)
+		}
+		rows = append(rows, hexRow(h.data[start:end], start, per, cursor, func(i int) bool {
+			return h.modified[start+int64(i)]
+		}))
+	}
+	if len(rows) == 0 {
+		rows = []string{lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("(empty file)")}
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (h *hexEditor) statusLine() string {
+	side := "HEX"
+	if h.ascii {
+		side = "ASCII"
+	}
+	var val byte
+	if len(h.data) > 0 {
+		val = h.data[h.cursor]
+	}
+	return fmt.Sprintf("offset 0x%08x (%d) / %d  │  byte 0x%02x %3d  │  %s  │  %d modified",
+		h.cursor, h.cursor, len(h.data), val, val, side, len(h.modified))
+}
+
+// updateHexEditor handles key input while in hexMode.
+func (m *Model) updateHexEditor(msg tea.KeyMsg) tea.Cmd {
+	h := &m.hex
+	if h.prompt != hexPromptNone {
+		switch msg.String() {
+			case "esc":
+				h.prompt = hexPromptNone
+				h.input.Blur()
+			case "enter":
+				val := h.input.Value()
+				prompt := h.prompt
+				h.prompt = hexPromptNone
+				h.input.Blur()
+				if prompt == hexPromptGoto {
+					off, err := parseOffset(val, int64(len(h.data)))
+					if err != nil {
+						m.statusMsg = errorStyle.Render("Invalid offset: " + val)
+						return nil
+					}
+					h.moveTo(off)
+					return nil
+				}
+				pattern, err := parseBytePattern(val)
+				if err != nil {
+					m.statusMsg = errorStyle.Render("Invalid pattern: " + err.Error())
+					return nil
+				}
+				h.pattern = pattern
+				m.hexFindNext(h.cursor)
+			default:
+				var cmd tea.Cmd
+				h.input, cmd = h.input.Update(msg)
+				return cmd
+		}
+		return nil
+	}
+
+	per := int64(h.perRow())
+	switch msg.String() {
+		case "esc":
+			if h.dirty() {
+				m.askConfirm(fmt.Sprintf("Discard %d modified byte(s) in '%s'? (y/n)", len(h.modified), filepath.Base(h.file)),
+					     func(m *Model) tea.Cmd {
+						     m.mode = explorerMode
+						     m.commandInput.Focus()
+						     m.refreshPanel(m.activePanel)
+						     return nil
+					     })
+				return nil
+			}
+			m.mode = explorerMode
+			m.commandInput.Focus()
+		case "ctrl+s":
+			if err := h.save(); err != nil {
+				m.statusMsg = errorStyle.Render(fmt.Sprintf("Save error: %v", err))
+			} else {
+				m.statusMsg = successStyle.Render("Saved: " + h.file)
+			}
+		case "ctrl+g":
+			h.prompt = hexPromptGoto
+			h.input.Reset()
+			h.input.Placeholder = "offset (1234, 0x4d2, 50%)"
+			return h.input.Focus()
+		case "ctrl+f":
+			h.prompt = hexPromptSearch
+			h.input.Reset()
+			h.input.Placeholder = `bytes (de ad be ef) or "text"`
+			return h.input.Focus()
+		case "ctrl+n":
+			m.hexFindNext(h.cursor + 1)
+		case "tab":
+			h.ascii = !h.ascii
+			h.lowNib = false
+		case "left":
+			h.moveTo(h.cursor - 1)
+		case "right":
+			h.moveTo(h.cursor + 1)
+		case "up":
+			h.moveTo(h.cursor - per)
+		case "down":
+			h.moveTo(h.cursor + per)
+		case "pgup":
+			h.moveTo(h.cursor - per*int64(h.height))
+		case "pgdown":
+			h.moveTo(h.cursor + per*int64(h.height))
+		case "home":
+			h.moveTo(h.cursor - h.cursor%per)
+		case "end":
+			h.moveTo(h.cursor - h.cursor%per + per - 1)
+		case "ctrl+home":
+			h.moveTo(0)
+		case "ctrl+end":
+			h.moveTo(int64(len(h.data)) - 1)
+		default:
+			if msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+				if h.ascii {
+					h.typeASCII(msg.Runes[0])
+				} else {
+					h.typeHex(msg.Runes[0])
+				}
+			}
+	}
+	return nil
+}
+
+func (m *Model) hexFindNext(from int64) {
+	h := &m.hex
+	if len(h.pattern) == 0 {
+		m.statusMsg = warnStyle.Render("No search pattern (Ctrl+F)")
+		return
+	}
+	off, ok := h.search(h.pattern, from)
+	if !ok {
+		m.statusMsg = warnStyle.Render("Pattern not found")
+		return
+	}
+	h.moveTo(off)
+	m.statusMsg = successStyle.Render(fmt.Sprintf("Found at 0x%x", off))
+}
@@ -0,0 +1,360 @@
+package src
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ─── Inline image preview ─────────────────────────────────────────────────────
+
+type imageProtocol int
+
+const (
+	imageHalfBlock imageProtocol = iota
+	imageKitty
+	imageSixel
+	imageNone
+)
+
+func (p imageProtocol) String() string {
+	return [...]string{"half-block", "kitty", "sixel", "none"}[p]
+}
+
+// maxImagePixels guards against decompression bombs in previews.
+const maxImagePixels = 50 * 1000 * 1000
+
+// detectImageProtocol honours NGT_IMAGE_PROTOCOL, then looks at the usual
+// environment hints. Sixel support cannot be detected reliably without a
+// terminal query, so only terminals known to support it are listed.
+func detectImageProtocol() imageProtocol {
+	switch strings.ToLower(os.Getenv("NGT_IMAGE_PROTOCOL")) {
+		case "kitty":
+			return imageKitty
+		case "sixel":
+			return imageSixel
+		case "halfblock", "half-block", "ansi":
+			return imageHalfBlock
+		case "none", "off":
+			return imageNone
+	}
+	term, prog := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+		case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", prog == "ghostty":
+			return imageKitty
+		case prog == "WezTerm", term == "foot", strings.HasPrefix(term, "foot-"), term == "mlterm", term == "yaft-256color", strings.Contains(term, "sixel"):
+			return imageSixel
+	}
+	return imageHalfBlock
+}
+
+// decodePreviewImage decodes r after checking the header for sane dimensions.
+func decodePreviewImage(vfs vfsHandler, path string) (image.Image, string, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, format, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, format, fmt.Errorf("image too large to preview (%d×%d)", cfg.Width, cfg.Height)
+	}
+	f, err = vfs.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	img, format, err := image.Decode(f)
+	return img, format, err
+}
+
+// fitCells returns the largest cols×rows box within maxCols×maxRows that
+// keeps the image aspect ratio, given the pixel size of one cell.
+func fitCells(imgW, imgH, maxCols, maxRows, cellW, cellH int) (int, int) {
+	if imgW == 0 || imgH == 0 || maxCols <= 0 || maxRows <= 0 {
+		return 0, 0
+	}
+	cols := maxCols
+	rows := (imgH * cols * cellW) / (imgW * cellH)
+	if rows > maxRows {
+		rows = maxRows
+		cols = (imgW * rows * cellH) / (imgH * cellW)
+	}
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return cols, rows
+}
+
+func scaleImage(src image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// renderHalfBlocks draws two pixels per cell with '▀' using true colour.
+func renderHalfBlocks(src image.Image, cols, rows int) string {
+	img := scaleImage(src, cols, rows*2)
+	var sb strings.Builder
+	for y := 0; y < rows*2; y += 2 {
+		for x := 0; x < cols; x++ {
+			t := img.RGBAAt(x, y)
+			b := img.RGBAAt(x, y+1)
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", t.R, t.G, t.B, b.R, b.G, b.B)
+		}
+		sb.WriteString("\x1b[0m")
+		if y+2 < rows*2 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// ─── Kitty graphics protocol ──────────────────────────────────────────────────
+
+// kittyImageID is bumped for every transmitted image; the id is carried in
+// the foreground colour of the Unicode placeholders.
+var kittyImageID uint32
+
+// kittyTransmit uploads img as PNG with a virtual placement of cols×rows
+// cells, deleting the previously shown image first.
+func kittyTransmit(img image.Image, id uint32, cols, rows int) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+	var sb strings.Builder
+	if id > 1 {
+		fmt.Fprintf(&sb, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", id-1)
+	}
+	const chunk = 4096
+	for i := 0; i < len(payload); i += chunk {
+		end := i + chunk
+		more := 1
+		if end >= len(payload) {
+			end, more = len(payload), 0
+		}
+		if i == 0 {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,U=1,f=100,q=2,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", id, cols, rows, more, payload[i:end])
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+	return sb.String(), nil
+}
+
+// kittyPlaceholders lays out U+10EEEE cells; only the first cell of each
+// row carries row/column diacritics, the rest are inferred by the terminal.
+func kittyPlaceholders(id uint32, cols, rows int) string {
+	var sb strings.Builder
+	for r := 0; r < rows && r < len(kittyDiacritics); r++ {
+		fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm", (id>>16)&0xff, (id>>8)&0xff, id&0xff)
+		sb.WriteRune('\U0010EEEE')
+		sb.WriteRune(kittyDiacritics[r])
+		sb.WriteRune(kittyDiacritics[0])
+		for c := 1; c < cols; c++ {
+			sb.WriteRune('\U0010EEEE')
+		}
+		sb.WriteString("\x1b[39m")
+		if r+1 < rows {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// kittyDiacritics is the head of kitty's rowcolumn-diacritics.txt.
+var kittyDiacritics = []rune{
+	'\u0305', '\u030D', '\u030E', '\u0310', '\u0312', '\u033D', '\u033E', '\u033F',
+	'\u0346', '\u034A', '\u034B', '\u034C', '\u0350', '\u0351', '\u0352', '\u0357',
+	'\u035B', '\u0363', '\u0364', '\u0365', '\u0366', '\u0367', '\u0368', '\u0369',
+	'\u036A', '\u036B', '\u036C', '\u036D', '\u036E', '\u036F', '\u0483', '\u0484',
+	'\u0485', '\u0486', '\u0487', '\u0592', '\u0593', '\u0594', '\u0595', '\u0597',
+	'\u0598', '\u0599', '\u059C', '\u059D', '\u059E', '\u059F', '\u05A0', '\u05A1',
+	'\u05A8', '\u05A9', '\u05AB', '\u05AC', '\u05AF', '\u05C4', '\u0610', '\u0611',
+	'\u0612', '\u0613', '\u0614', '\u0615', '\u0616', '\u0617', '\u0657', '\u0658',
+	'\u0659', '\u065A', '\u065B', '\u065D', '\u065E', '\u06D6', '\u06D7', '\u06D8',
+	'\u06D9', '\u06DA', '\u06DB', '\u06DC', '\u06DF', '\u06E0', '\u06E1', '\u06E2',
+	'\u06E4', '\u06E7', '\u06E8', '\u06EB', '\u06EC', '\u0730', '\u0732', '\u0733',
+	'\u0735', '\u0736', '\u073A', '\u073D', '\u073F', '\u0740', '\u0741', '\u0743',
+	'\u0745', '\u0747', '\u0749', '\u074A', '\u07EB', '\u07EC', '\u07ED', '\u07EE',
+	'\u07EF', '\u07F0', '\u07F1', '\u07F3', '\u0816', '\u0817', '\u0818', '\u0819',
+	'\u081B', '\u081C', '\u081D', '\u081E', '\u081F', '\u0820', '\u0821', '\u0822',
+	'\u0823', '\u0825', '\u0826', '\u0827', '\u0829', '\u082A', '\u082B', '\u082C',
+}
+
+// ─── Sixel ────────────────────────────────────────────────────────────────────
+
+// encodeSixel quantises img to the Plan 9 palette with Floyd–Steinberg
+// dithering and emits a DCS sixel sequence with run-length encoding.
+func encodeSixel(img image.Image) string {
+	b := img.Bounds()
+	pal := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), palette.Plan9)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), img, b.Min)
+	w, h := pal.Bounds().Dx(), pal.Bounds().Dy()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	used := make([]bool, len(palette.Plan9))
+	for _, ci := range pal.Pix {
+		used[ci] = true
+	}
+	for i, c := range palette.Plan9 {
+		if !used[i] {
+			continue
+		}
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+	row := make([]byte, w)
+	for band := 0; band < h; band += 6 {
+		first := true
+		for ci := range palette.Plan9 {
+			if !used[ci] {
+				continue
+			}
+			any := false
+			for x := 0; x < w; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if pal.Pix[(band+dy)*pal.Stride+x] == uint8(ci) {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = bits
+				any = any || bits != 0
+			}
+			if !any {
+				continue
+			}
+			if !first {
+				sb.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&sb, "#%d", ci)
+			writeSixelRow(&sb, row)
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+func writeSixelRow(sb *strings.Builder, row []byte) {
+	for x := 0; x < len(row); {
+		n := 1
+		for x+n < len(row) && row[x+n] == row[x] {
+			n++
+		}
+		ch := byte(63 + row[x])
+		if n > 3 {
+			fmt.Fprintf(sb, "!%d%c", n, ch)
+		} else {
+			for i := 0; i < n; i++ {
+				sb.WriteByte(ch)
+			}
+		}
+		x += n
+	}
+}
+
+// ─── Preview glue ─────────────────────────────────────────────────────────────
+
+// imagePreview renders img for the preview pane described by env. Protocol
+// escapes that must bypass the renderer are returned in graphics.
+func (env previewEnv) imagePreview(img image.Image, format string, size int64) previewResult {
+	b := img.Bounds()
+	info := fmt.Sprintf("%d×%d %s · %s", b.Dx(), b.Dy(), strings.ToUpper(format), humanSize(size))
+	maxCols, maxRows := env.width, env.height-2
+	proto := env.proto
+	if proto == imageSixel && !env.visible {
+		proto = imageHalfBlock
+	}
+	if proto == imageNone || maxCols < 4 || maxRows < 2 {
+		return previewResult{content: "🖼  " + info}
+	}
+	cellW, cellH := termCellSize()
+	switch proto {
+		case imageKitty:
+			cols, rows := fitCells(b.Dx(), b.Dy(), maxCols, maxRows, cellW, cellH)
+			if rows > len(kittyDiacritics) {
+				rows = len(kittyDiacritics)
+			}
+			// Send at most the pixels the placement can show.
+			scaled := scaleImage(img, cols*cellW, rows*cellH)
+			id := atomic.AddUint32(&kittyImageID, 1)
+			seq, err := kittyTransmit(scaled, id, cols, rows)
+			if err != nil {
+				return previewResult{content: "🖼  " + info + "\n" + err.Error()}
+			}
+			return previewResult{content: kittyPlaceholders(id, cols, rows) + "\n" + info, graphics: seq}
+		case imageSixel:
+			cols, rows := fitCells(b.Dx(), b.Dy(), maxCols, maxRows, cellW, cellH)
+			scaled := scaleImage(img, cols*cellW, rows*cellH)
+			return previewResult{
+				content:  strings.Repeat("\n", rows) + info,
+				graphics: fmt.Sprintf("\x1b7\x1b[%d;%dH%s\x1b8", env.originY+1, env.originX+1, encodeSixel(scaled)),
+			}
+	}
+	cols, rows := fitCells(b.Dx(), b.Dy(), maxCols, maxRows, 1, 2)
+	return previewResult{content: renderHalfBlocks(img, cols, rows) + "\n" + info}
+}
+
+// previewOrigin returns the 0-based screen cell where panel idx's preview
+// content starts, and whether the preview is on screen at all.
+func (m *Model) previewOrigin(idx int) (int, int, bool) {
+	if m.termW == 0 || m.mode != explorerMode {
+		return 0, 0, false
+	}
+	l := m.panelLayout(idx)
+	return l.previewX, l.previewY, l.previewH > 0
+}
+
+// TermOutput is the terminal the program renders to. main hands it to
+// tea.WithOutput so that image escapes go out through the same writer as the
+// renderer's frames rather than to the file descriptor behind its back.
+var TermOutput = termenv.NewOutput(os.Stdout)
+
+// graphicsDelay lets the renderer flush the frame that lays out the preview
+// (it ticks at 60 fps) before the image is drawn into it; otherwise the
+// frame can paint over a sixel image right after it appears.
+const graphicsDelay = 2 * time.Second / 60
+
+// flushGraphics collects queued protocol escapes into a command that writes
+// them to TermOutput once the matching frame is on screen. Each sequence is a
+// single write, like a frame, so the two never interleave.
+func (m *Model) flushGraphics() tea.Cmd {
+	var seq string
+	for i := range m.panels {
+		seq += m.panels[i].previewGraphics
+		m.panels[i].previewGraphics = ""
+	}
+	if seq == "" {
+		return nil
+	}
+	return tea.Tick(graphicsDelay, func(time.Time) tea.Msg {
+		TermOutput.WriteString(seq)
+		return nil
+	})
+}
@@ -0,0 +1,913 @@
+package src
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ─── Path index ───────────────────────────────────────────────────────────────
+
+const (
+	indexVersion   = 1
+	indexWorkers   = 8
+	indexDebounce  = 300 * time.Millisecond
+	indexSaveEvery = 30 * time.Second
+)
+
+// indexConfig is read from $XDG_CONFIG_HOME/ngt/index.json; without it
+// nothing is indexed and searches walk the tree as before.
+//
+//	{"roots": ["~/src/monorepo"], "contents": true}
+//
+// contents adds trigrams of file text so grep only opens files that can
+// match.
+type indexConfig struct {
+	Roots    []string `json:"roots"`
+	Contents bool     `json:"contents,omitempty"`
+}
+
+func indexConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, "index.json")
+}
+
+// loadIndexConfig reads the index configuration; a missing file means no
+// roots.
+func loadIndexConfig() (indexConfig, error) {
+	var cfg indexConfig
+	path := indexConfigPath()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// indexer keeps one fileIndex per configured root up to date in the
+// background.
+type indexer struct {
+	indexes []*fileIndex
+}
+
+// startIndexer loads the configuration and starts indexing its roots.
+func startIndexer() (*indexer, error) {
+	cfg, err := loadIndexConfig()
+	if err != nil || len(cfg.Roots) == 0 {
+		return nil, err
+	}
+	home, _ := os.UserHomeDir()
+	ix := &indexer{}
+	for _, root := range cfg.Roots {
+		if rest, ok := strings.CutPrefix(root, "~"); ok && home != "" {
+			root = home + rest
+		}
+		root, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		fi := newFileIndex(root, cfg.Contents)
+		ix.indexes = append(ix.indexes, fi)
+		go fi.run()
+	}
+	return ix, nil
+}
+
+// covering returns the ready index whose root contains dir, or nil when dir
+// has to be walked.
+func (ix *indexer) covering(vfs vfsHandler, dir string) *fileIndex {
+	if ix == nil {
+		return nil
+	}
+	if _, ok := vfs.(localVFS); !ok {
+		return nil
+	}
+	for _, fi := range ix.indexes {
+		rel, err := filepath.Rel(fi.root, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		fi.mu.RLock()
+		_, indexed := fi.byPath[rel]
+		ok := fi.ready && (rel == "." || indexed)
+		fi.mu.RUnlock()
+		if ok {
+			return fi
+		}
+	}
+	return nil
+}
+
+// walkTree streams the entries under root like fuzzyWalk, from an index
+// when one covers root; re, if set, lets the index skip files that cannot
+// match it.
+func (ix *indexer) walkTree(ctx context.Context, vfs vfsHandler, root string, re *regexp.Regexp, out chan<- []fuzzyEntry, limited *atomic.Bool) {
+	if fi := ix.covering(vfs, root); fi != nil {
+		fi.walk(ctx, root, re, out, limited)
+		return
+	}
+	fuzzyWalk(ctx, vfs, root, out, limited)
+}
+
+// status describes every index, one line each.
+func (ix *indexer) status() string {
+	if ix == nil {
+		return "No index roots configured in " + indexConfigPath()
+	}
+	lines := []string{"Index (" + indexConfigPath() + "):"}
+	for _, fi := range ix.indexes {
+		lines = append(lines, "  "+fi.status())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rebuild drops every index and scans the roots again.
+func (ix *indexer) rebuild() {
+	if ix == nil {
+		return
+	}
+	for _, fi := range ix.indexes {
+		select {
+			case fi.rebuildCh <- struct{}{}:
+			default:
+		}
+	}
+}
+
+// fileIndex is the index of one root. Entries get ascending ids; a changed
+// entry is dropped (its path blanked) and added again under a new id, so
+// posting lists only ever grow at the end and stay sorted. Dead ids are
+// squeezed out once they outnumber the live ones.
+type fileIndex struct {
+	root      string
+	contents  bool
+	rebuildCh chan struct{}
+
+	// owned by the run goroutine
+	rules   map[string]ignoreRules // per indexed directory, rel to root
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+
+	mu       sync.RWMutex
+	ready    bool
+	state    string
+	err      error
+	paths    []string // by id, rel to root; "" once dropped
+	dirs     []bool
+	mtimes   []int64
+	byPath   map[string]uint32
+	grams    map[uint32][]uint32 // trigram → ids of files containing it
+	dead     int
+	dirty    bool
+	savedAt  time.Time
+	watching int // directories with a watch, -1 without inotify
+}
+
+// indexFile is the on-disk form; posting lists are delta-encoded uvarints
+// and the whole file is gzipped.
+type indexFile struct {
+	Version  int
+	Root     string
+	Contents bool
+	Paths    []string
+	Dirs     []bool
+	MTimes   []int64
+	Grams    map[uint32][]byte
+}
+
+// indexChange is a new or modified entry found by reconcile.
+type indexChange struct {
+	rel   string
+	isDir bool
+	mtime int64
+	grams []uint32
+}
+
+func newFileIndex(root string, contents bool) *fileIndex {
+	fi := &fileIndex{root: root, contents: contents, rebuildCh: make(chan struct{}, 1), state: "loading"}
+	fi.reset()
+	return fi
+}
+
+func (fi *fileIndex) reset() {
+	fi.paths, fi.dirs, fi.mtimes = nil, nil, nil
+	fi.byPath = make(map[string]uint32)
+	fi.grams = make(map[uint32][]uint32)
+	fi.dead = 0
+	fi.rules = make(map[string]ignoreRules)
+	fi.watched = make(map[string]bool)
+}
+
+func (fi *fileIndex) setState(state string) {
+	fi.mu.Lock()
+	fi.state = state
+	fi.mu.Unlock()
+}
+
+func (fi *fileIndex) setErr(err error) {
+	fi.mu.Lock()
+	fi.err = err
+	fi.mu.Unlock()
+}
+
+func (fi *fileIndex) status() string {
+	fi.mu.RLock()
+	defer fi.mu.RUnlock()
+	postings := 0
+	for _, ids := range fi.grams {
+		postings += len(ids)
+	}
+	s := fmt.Sprintf("%s: %s, %d paths", fi.root, fi.state, len(fi.paths)-fi.dead)
+	if fi.contents {
+		s += fmt.Sprintf(", %d trigrams (%d postings)", len(fi.grams), postings)
+	}
+	if fi.watching >= 0 {
+		s += fmt.Sprintf(", %d dirs watched", fi.watching)
+	}
+	if !fi.savedAt.IsZero() {
+		s += ", saved " + fi.savedAt.Format("15:04:05")
+	}
+	if fi.err != nil {
+		s += " – " + fi.err.Error()
+	}
+	return s
+}
+
+// cachePath is where the index of root is kept between runs.
+func (fi *fileIndex) cachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fi.root))
+	return filepath.Join(dir, appName, "index", hex.EncodeToString(sum[:8])+".gob.gz")
+}
+
+// run loads the saved index, brings it up to date and then follows
+// filesystem events until the program exits.
+func (fi *fileIndex) run() {
+	if fi.load() == nil {
+		fi.mu.Lock()
+		fi.ready = true
+		fi.mu.Unlock()
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		fi.mu.Lock()
+		fi.err, fi.watching = fmt.Errorf("inotify: %w", err), -1
+		fi.mu.Unlock()
+	} else {
+		fi.watcher = w
+		defer w.Close()
+	}
+	fi.setState("scanning")
+	fi.reconcile("", true)
+	fi.mu.Lock()
+	fi.ready = true
+	fi.mu.Unlock()
+	fi.save()
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if fi.watcher != nil {
+		events, errs = fi.watcher.Events, fi.watcher.Errors
+		fi.setState("watching")
+	} else {
+		fi.setState("ready")
+	}
+	// Events are gathered for one window from the first of them, so a file
+	// written continuously can't postpone reconciling forever.
+	pending := make(map[string]bool)
+	full := false
+	var window <-chan time.Time
+	save := time.NewTicker(indexSaveEvery)
+	defer save.Stop()
+	for {
+		select {
+			case ev := <-events:
+				rel, err := filepath.Rel(fi.root, ev.Name)
+				if err != nil || rel == "." {
+					continue
+				}
+				dir := parentRel(rel)
+				if filepath.Base(rel) == ".gitignore" {
+					full = true
+				} else if rules, ok := fi.rules[dir]; !ok || rules.ignored(filepath.ToSlash(rel), ev.Op&fsnotify.Create != 0 && isDirPath(ev.Name)) {
+					continue
+				}
+				pending[dir] = true
+				if window == nil {
+					window = time.After(indexDebounce)
+				}
+			case err := <-errs:
+				// Usually an event queue overflow: events were lost.
+				fi.setErr(err)
+				full = true
+				if window == nil {
+					window = time.After(indexDebounce)
+				}
+			case <-window:
+				window = nil
+				if full {
+					fi.reconcile("", true)
+				} else {
+					for dir := range pending {
+						fi.reconcile(dir, false)
+					}
+				}
+				pending, full = make(map[string]bool), false
+			case <-save.C:
+				fi.save()
+			case <-fi.rebuildCh:
+				state := fi.state
+				fi.setState("scanning")
+				fi.mu.Lock()
+				fi.reset()
+				fi.err = nil
+				fi.mu.Unlock()
+				fi.reconcile("", true)
+				fi.save()
+				fi.setState(state)
+		}
+	}
+}
+
+// parentRel is the directory of rel, "" for the root.
+func parentRel(rel string) string {
+	if dir := filepath.Dir(rel); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+func isDirPath(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// reconcile brings the entries under relDir in line with the disk: all of
+// them when recursive, otherwise relDir's children plus any new
+// subdirectories.
+func (fi *fileIndex) reconcile(relDir string, recursive bool) {
+	rules, ok := fi.rules[parentRel(relDir)]
+	if relDir == "" {
+		rules, ok = nil, true
+	}
+	if !ok {
+		return
+	}
+	fi.mu.RLock()
+	seen := make(map[string]bool)
+	var changed []indexChange
+	var watchErr error
+	var visit func(rel string, rules ignoreRules, recurse bool)
+	visit = func(rel string, rules ignoreRules, recurse bool) {
+		abs := filepath.Join(fi.root, rel)
+		entries, err := os.ReadDir(abs)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if e.Name() == ".gitignore" && !e.IsDir() {
+				rules = loadGitignore(localVFS{}, abs, filepath.ToSlash(rel), rules)
+				break
+			}
+		}
+		fi.rules[rel] = rules
+		if err := fi.watch(abs); err != nil {
+			watchErr = err
+		}
+		for _, e := range entries {
+			r := filepath.Join(rel, e.Name())
+			if e.Name() == ".git" || rules.ignored(filepath.ToSlash(r), e.IsDir()) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			seen[r] = true
+			mtime := info.ModTime().UnixNano()
+			id, known := fi.byPath[r]
+			if !known || fi.dirs[id] != e.IsDir() || fi.mtimes[id] != mtime {
+				changed = append(changed, indexChange{rel: r, isDir: e.IsDir(), mtime: mtime})
+			}
+			if e.IsDir() && (recurse || !known) {
+				visit(r, rules, true)
+			}
+		}
+	}
+	visit(relDir, rules, recursive)
+	fi.mu.RUnlock()
+
+	if fi.contents {
+		fi.readGrams(changed)
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if watchErr != nil {
+		fi.err = watchErr
+	}
+	// An entry is gone when it (non-recursive: its top-level ancestor below
+	// relDir) was not seen.
+	prefix := ""
+	if relDir != "" {
+		prefix = relDir + string(filepath.Separator)
+	}
+	for id, p := range fi.paths {
+		if p == "" || !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		key := p
+		if !recursive {
+			child, _, _ := strings.Cut(p[len(prefix):], string(filepath.Separator))
+			key = prefix + child
+		}
+		if !seen[key] {
+			fi.drop(uint32(id))
+		}
+	}
+	for _, c := range changed {
+		id, ok := fi.byPath[c.rel]
+		if ok && c.isDir && fi.dirs[id] {
+			// Only the listing changed; keep the id, rules and watch.
+			fi.mtimes[id] = c.mtime
+			continue
+		}
+		if ok {
+			fi.drop(id)
+		}
+		fi.add(c)
+	}
+	if len(changed) > 0 || fi.dead > 0 {
+		fi.dirty = true
+	}
+	if fi.dead > len(fi.paths)-fi.dead {
+		fi.compact()
+	}
+	if fi.watcher != nil {
+		fi.watching = len(fi.watched)
+	}
+}
+
+// watch adds an inotify watch for a directory once; past the system's
+// watch limit the rest of the tree goes stale until the next start.
+func (fi *fileIndex) watch(abs string) error {
+	if fi.watcher == nil || fi.watched[abs] {
+		return nil
+	}
+	if err := fi.watcher.Add(abs); err != nil {
+		return fmt.Errorf("watch %s: %w", abs, err)
+	}
+	fi.watched[abs] = true
+	return nil
+}
+
+// readGrams fills in the trigrams of changed files, several at a time.
+// Files grep would skip (binary or too large to edit) get none.
+func (fi *fileIndex) readGrams(changed []indexChange) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < indexWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(changed) {
+					return
+				}
+				if changed[i].isDir {
+					continue
+				}
+				changed[i].grams = fileTrigrams(filepath.Join(fi.root, changed[i].rel))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func fileTrigrams(path string) []uint32 {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSizeForEdit {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	format := detectFormat(data)
+	if !format.isUTF16() && looksBinary(data) {
+		return nil
+	}
+	text, err := decodeText(data, format)
+	if err != nil {
+		return nil
+	}
+	return textTrigrams(text)
+}
+
+// textTrigrams returns the sorted distinct trigrams of text with ASCII
+// letters folded to lower case; grep is line-based, so none spans a newline.
+func textTrigrams(text string) []uint32 {
+	set := make(map[uint32]struct{})
+	for i := 0; i+3 <= len(text); i++ {
+		a, b, c := text[i], text[i+1], text[i+2]
+		if a == '\n' || b == '\n' || c == '\n' {
+			continue
+		}
+		set[trigram(a, b, c)] = struct{}{}
+	}
+	grams := make([]uint32, 0, len(set))
+	for g := range set {
+		grams = append(grams, g)
+	}
+	sort.Slice(grams, func(i, j int) bool { return grams[i] < grams[j] })
+	return grams
+}
+
+func trigram(a, b, c byte) uint32 {
+	return uint32(lowerASCII(a))<<16 | uint32(lowerASCII(b))<<8 | uint32(lowerASCII(c))
+}
+
+func lowerASCII(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// drop blanks an entry; its id stays in the posting lists until compact.
+func (fi *fileIndex) drop(id uint32) {
+	p := fi.paths[id]
+	delete(fi.byPath, p)
+	if fi.dirs[id] {
+		abs := filepath.Join(fi.root, p)
+		delete(fi.watched, abs)
+		delete(fi.rules, p)
+	}
+	fi.paths[id] = ""
+	fi.dead++
+}
+
+func (fi *fileIndex) add(c indexChange) {
+	id := uint32(len(fi.paths))
+	fi.paths = append(fi.paths, c.rel)
+	fi.dirs = append(fi.dirs, c.isDir)
+	fi.mtimes = append(fi.mtimes, c.mtime)
+	fi.byPath[c.rel] = id
+	for _, g := range c.grams {
+		fi.grams[g] = append(fi.grams[g], id)
+	}
+}
+
+// compact renumbers the live entries and drops dead ids from the posting
+// lists.
+func (fi *fileIndex) compact() {
+	remap := make([]int64, len(fi.paths))
+	var paths []string
+	var dirs []bool
+	var mtimes []int64
+	for id, p := range fi.paths {
+		remap[id] = -1
+		if p == "" {
+			continue
+		}
+		remap[id] = int64(len(paths))
+		fi.byPath[p] = uint32(len(paths))
+		paths = append(paths, p)
+		dirs = append(dirs, fi.dirs[id])
+		mtimes = append(mtimes, fi.mtimes[id])
+	}
+	for g, ids := range fi.grams {
+		kept := ids[:0]
+		for _, id := range ids {
+			if remap[id] >= 0 {
+				kept = append(kept, uint32(remap[id]))
+			}
+		}
+		if len(kept) == 0 {
+			delete(fi.grams, g)
+		} else {
+			fi.grams[g] = kept
+		}
+	}
+	fi.paths, fi.dirs, fi.mtimes, fi.dead = paths, dirs, mtimes, 0
+}
+
+// save writes the index to the cache directory when it has changed.
+func (fi *fileIndex) save() {
+	path := fi.cachePath()
+	fi.mu.RLock()
+	if path == "" || !fi.dirty {
+		fi.mu.RUnlock()
+		return
+	}
+	file := indexFile{
+		Version:  indexVersion,
+		Root:     fi.root,
+		Contents: fi.contents,
+		Paths:    fi.paths,
+		Dirs:     fi.dirs,
+		MTimes:   fi.mtimes,
+		Grams:    make(map[uint32][]byte, len(fi.grams)),
+	}
+	for g, ids := range fi.grams {
+		file.Grams[g] = encodePostings(ids)
+	}
+	err := writeIndexFile(path, &file)
+	fi.mu.RUnlock()
+
+	fi.mu.Lock()
+	if err != nil {
+		fi.err = fmt.Errorf("save: %w", err)
+	} else {
+		fi.dirty, fi.savedAt = false, time.Now()
+	}
+	fi.mu.Unlock()
+}
+
+func writeIndexFile(path string, file *indexFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	err = gob.NewEncoder(zw).Encode(file)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// load reads the saved index, if there is one for this root and settings.
+func (fi *fileIndex) load() error {
+	f, err := os.Open(fi.cachePath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	var file indexFile
+	if err := gob.NewDecoder(zr).Decode(&file); err != nil {
+		return err
+	}
+	if file.Version != indexVersion || file.Root != fi.root || file.Contents != fi.contents ||
+		len(file.Dirs) != len(file.Paths) || len(file.MTimes) != len(file.Paths) {
+		return fmt.Errorf("stale index")
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.paths, fi.dirs, fi.mtimes = file.Paths, file.Dirs, file.MTimes
+	for id, p := range fi.paths {
+		if p == "" {
+			fi.dead++
+			continue
+		}
+		fi.byPath[p] = uint32(id)
+	}
+	for g, data := range file.Grams {
+		ids := decodePostings(data)
+		if len(ids) > 0 && int(ids[len(ids)-1]) >= len(fi.paths) {
+			return fmt.Errorf("corrupt index")
+		}
+		fi.grams[g] = ids
+	}
+	// Directories seen before get their .gitignore rules on the first
+	// reconcile, which follows immediately.
+	return nil
+}
+
+func encodePostings(ids []uint32) []byte {
+	buf := make([]byte, 0, len(ids)*2)
+	prev := uint32(0)
+	for _, id := range ids {
+		buf = binary.AppendUvarint(buf, uint64(id-prev))
+		prev = id
+	}
+	return buf
+}
+
+func decodePostings(data []byte) []uint32 {
+	var ids []uint32
+	prev := uint64(0)
+	for len(data) > 0 {
+		d, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		prev += d
+		ids = append(ids, uint32(prev))
+		data = data[n:]
+	}
+	return ids
+}
+
+// ─── Index queries ────────────────────────────────────────────────────────────
+
+// walk streams the indexed entries under root like fuzzyWalk does. With re
+// and a contents index, only files that can contain re's literals are sent.
+func (fi *fileIndex) walk(ctx context.Context, root string, re *regexp.Regexp, out chan<- []fuzzyEntry, limited *atomic.Bool) {
+	defer close(out)
+	prefix, err := filepath.Rel(fi.root, root)
+	if err != nil {
+		return
+	}
+	if prefix == "." {
+		prefix = ""
+	} else {
+		prefix += string(filepath.Separator)
+	}
+	var found []fuzzyEntry
+	send := func(id uint32) bool {
+		p := fi.paths[id]
+		if p == "" || !strings.HasPrefix(p, prefix) {
+			return true
+		}
+		rel := p[len(prefix):]
+		if strings.Count(rel, string(filepath.Separator)) >= fuzzyMaxDepth {
+			limited.Store(true)
+			return true
+		}
+		if len(found) == fuzzyMaxEntries {
+			limited.Store(true)
+			return false
+		}
+		found = append(found, fuzzyEntry{rel: rel, isDir: fi.dirs[id], modTime: time.Unix(0, fi.mtimes[id])})
+		return true
+	}
+
+	fi.mu.RLock()
+	var ids []uint32
+	narrowed := false
+	if re != nil && fi.contents {
+		ids, narrowed = fi.lookup(regexpTrigrams(re))
+	}
+	if narrowed {
+		for _, id := range ids {
+			if !send(id) {
+				break
+			}
+		}
+	} else {
+		for id := range fi.paths {
+			if !send(uint32(id)) {
+				break
+			}
+		}
+	}
+	fi.mu.RUnlock()
+
+	for len(found) > 0 {
+		n := min(len(found), fuzzyBatchSize)
+		select {
+			case out <- found[:n]:
+				found = found[n:]
+			case <-ctx.Done():
+				return
+		}
+	}
+}
+
+// lookup intersects the posting lists of grams; ok is false when there is
+// nothing to narrow by.
+func (fi *fileIndex) lookup(grams []uint32) (ids []uint32, ok bool) {
+	if len(grams) == 0 {
+		return nil, false
+	}
+	lists := make([][]uint32, len(grams))
+	for i, g := range grams {
+		lists[i] = fi.grams[g]
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	ids = lists[0]
+	for _, l := range lists[1:] {
+		if len(ids) == 0 {
+			break
+		}
+		var both []uint32
+		for i, j := 0, 0; i < len(ids) && j < len(l); {
+			switch {
+				case ids[i] < l[j]:
+					i++
+				case ids[i] > l[j]:
+					j++
+				default:
+					both = append(both, ids[i])
+					i, j = i+1, j+1
+			}
+		}
+		ids = both
+	}
+	return ids, true
+}
+
+// regexpTrigrams returns trigrams every match of re must contain, taken
+// from the literals the pattern cannot match without.
+func regexpTrigrams(re *regexp.Regexp) []uint32 {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	set := make(map[uint32]struct{})
+	for _, lit := range requiredLiterals(parsed.Simplify()) {
+		for i := 0; i+3 <= len(lit.text); i++ {
+			a, b, c := lit.text[i], lit.text[i+1], lit.text[i+2]
+			if !indexableByte(a, lit.fold) || !indexableByte(b, lit.fold) || !indexableByte(c, lit.fold) {
+				continue
+			}
+			set[trigram(a, b, c)] = struct{}{}
+		}
+	}
+	grams := make([]uint32, 0, len(set))
+	for g := range set {
+		grams = append(grams, g)
+	}
+	return grams
+}
+
+// indexableByte reports whether a query byte can be looked up as is. Only
+// ASCII is folded in the index, and under (?i) k and s also match the
+// Kelvin and long-s signs, so they are left out too.
+func indexableByte(b byte, fold bool) bool {
+	if b >= 0x80 || b == '\n' {
+		return false
+	}
+	if fold {
+		switch lowerASCII(b) {
+			case 'k', 's':
+				return false
+		}
+	}
+	return true
+}
+
+type regexpLiteral struct {
+	text string
+	fold bool
+}
+
+// requiredLiterals walks the parts of a pattern that every match goes
+// through: concatenations, groups and repeats of at least one.
+func requiredLiterals(re *syntax.Regexp) []regexpLiteral {
+	switch re.Op {
+		case syntax.OpLiteral:
+			return []regexpLiteral{{string(re.Rune), re.Flags&syntax.FoldCase != 0}}
+		case syntax.OpCapture, syntax.OpPlus:
+			return requiredLiterals(re.Sub[0])
+		case syntax.OpRepeat:
+			if re.Min >= 1 {
+				return requiredLiterals(re.Sub[0])
+			}
+		case syntax.OpConcat:
+			var out []regexpLiteral
+			for _, sub := range re.Sub {
+				out = append(out, requiredLiterals(sub)...)
+			}
+			return out
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package src
+
+import (
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []regexpLiteral
+	}{
+		{`hello`, []regexpLiteral{{"hello", false}}},
+		{`(?i)hello`, []regexpLiteral{{"HELLO", true}}},
+		{`foo.*bar`, []regexpLiteral{{"foo", false}, {"bar", false}}},
+		{`foo|bar`, nil},
+		{`x(foo|bar)y`, []regexpLiteral{{"x", false}, {"y", false}}},
+		{`ab[cd]ef`, []regexpLiteral{{"ab", false}, {"ef", false}}},
+		{`(abc)?def`, []regexpLiteral{{"def", false}}},
+		{`(abc)*def`, []regexpLiteral{{"def", false}}},
+		{`(abc)+def`, []regexpLiteral{{"abc", false}, {"def", false}}},
+		{`(abc){2,}x`, []regexpLiteral{{"abc", false}, {"abc", false}, {"x", false}}},
+		{`(abc){0,2}x`, []regexpLiteral{{"x", false}}},
+	}
+	for _, tt := range tests {
+		re, err := syntax.Parse(tt.pattern, syntax.Perl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := requiredLiterals(re.Simplify()); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("requiredLiterals(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// The index may only narrow a search to files that contain every trigram of
+// the query, so each text the pattern matches must hold all of them.
+func TestRegexpTrigramsAreImpliedByMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		grams   int // how many trigrams the query keeps
+		matches []string
+	}{
+		{`hello`, 3, []string{"hello", "say hello there"}},
+		{`(?i)hello`, 3, []string{"HELLO", "HeLlO world"}},
+		{`(?i)kelvin`, 3, []string{"KELVIN", "Kelvin", "\u212aelvin"}},
+		{`(?i)master`, 1, []string{"MASTER", "maſter"}},
+		{`foo.*bar`, 2, []string{"foobar", "foo and bar"}},
+		{`foo|bar`, 0, []string{"foo", "bar"}},
+		{`pre(foo|bar)post`, 3, []string{"prefoopost", "prebarpost"}},
+		{`ab[cd]ef`, 0, []string{"abcef", "abdef"}},
+		{`err[a-z]+code`, 3, []string{"errxcode", "errorcode"}},
+		{`(abc)?defg`, 2, []string{"defg", "abcdefg"}},
+		{`(abc)*defg`, 2, []string{"defg", "abcabcdefg"}},
+		{`(abc)+defg`, 3, []string{"abcdefg", "abcabcdefg"}},
+		{`x(abc){2}y`, 1, []string{"xabcabcy"}},
+		{`caf\x{e9}s`, 1, []string{"cafés"}},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(tt.pattern)
+		grams := regexpTrigrams(re)
+		if len(grams) != tt.grams {
+			t.Errorf("regexpTrigrams(%q) kept %d trigrams, want %d", tt.pattern, len(grams), tt.grams)
+		}
+		for _, text := range tt.matches {
+			if !re.MatchString(text) {
+				t.Fatalf("%q does not match %q", tt.pattern, text)
+			}
+			have := make(map[uint32]bool)
+			for _, g := range textTrigrams(text) {
+				have[g] = true
+			}
+			for _, g := range grams {
+				if !have[g] {
+					t.Errorf("regexpTrigrams(%q) needs %06x, which %q lacks", tt.pattern, g, text)
+				}
+			}
+		}
+	}
+}
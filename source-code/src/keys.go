@@ -12,6 +12,14 @@ const (
 	bulkRenameMode
 	confirmMode
 	podmanMode
+	bufferListMode
+	hexMode
+	pagerMode
+	duMode
+	diffMode
+	syncMode
+	grepMode
+	replaceMode
 )
 
 type keyMap struct {
@@ -41,6 +49,23 @@ type keyMap struct {
 	podman     key.Binding
 	duplicate  key.Binding
 	props      key.Binding
+	view       key.Binding
+	edit       key.Binding
+
+	bufferList  key.Binding
+	bufferNext  key.Binding
+	bufferPrev  key.Binding
+	bufferClose key.Binding
+
+	cycleEncoding key.Binding
+	toggleEOL     key.Binding
+
+	previewFocus key.Binding
+	quickView    key.Binding
+	diskUsage    key.Binding
+	diff         key.Binding
+	compare      key.Binding
+	fuzzyEdit    key.Binding
 }
 
 func newKeyMap() keyMap {
@@ -71,6 +96,23 @@ func newKeyMap() keyMap {
 		podman:     key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("^D", "podman")),
 		duplicate:  key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("^U", "duplicate")),
 		props:      key.NewBinding(key.WithKeys("alt+enter"), key.WithHelp("Alt+Enter", "props")),
+		view:       key.NewBinding(key.WithKeys("f3"), key.WithHelp("F3", "view")),
+		edit:       key.NewBinding(key.WithKeys("f4"), key.WithHelp("F4", "$EDITOR")),
+
+		bufferList:  key.NewBinding(key.WithKeys("ctrl+l"), key.WithHelp("^L", "buffers")),
+		bufferNext:  key.NewBinding(key.WithKeys("ctrl+right"), key.WithHelp("^→", "next buffer")),
+		bufferPrev:  key.NewBinding(key.WithKeys("ctrl+left"), key.WithHelp("^←", "prev buffer")),
+		bufferClose: key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("^X", "close buffer")),
+
+		cycleEncoding: key.NewBinding(key.WithKeys("alt+e"), key.WithHelp("Alt+E", "encoding")),
+		toggleEOL:     key.NewBinding(key.WithKeys("alt+r"), key.WithHelp("Alt+R", "CRLF/LF")),
+
+		previewFocus: key.NewBinding(key.WithKeys("alt+p"), key.WithHelp("Alt+P", "focus preview")),
+		quickView:    key.NewBinding(key.WithKeys("ctrl+q"), key.WithHelp("^Q", "quick view")),
+		diskUsage:    key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("^G", "disk usage")),
+		diff:         key.NewBinding(key.WithKeys("alt+d"), key.WithHelp("Alt+D", "diff files")),
+		compare:      key.NewBinding(key.WithKeys("alt+c"), key.WithHelp("Alt+C", "compare dirs")),
+		fuzzyEdit:    key.NewBinding(key.WithKeys("alt+enter"), key.WithHelp("Alt+Enter", "edit result")),
 	}
 }
 
@@ -0,0 +1,827 @@
+package src
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// ─── Metadata previews ────────────────────────────────────────────────────────
+
+// metaMaxInMemory caps how much of a file without random access (podman,
+// archives) is buffered to inspect executables.
+const metaMaxInMemory = 64 * 1024 * 1024
+
+// metaField is one "Key: value" line of a metadata preview.
+type metaField struct{ key, value string }
+
+// renderMeta aligns fields under a title; empty values are skipped.
+func renderMeta(title string, fields []metaField) string {
+	width := 0
+	for _, f := range fields {
+		if f.value != "" {
+			width = max(width, len(f.key))
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(panelTitleStyle.Render(title) + "\n\n")
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		label := f.key
+		if label != "" {
+			label += ":"
+		}
+		sb.WriteString(metaKeyStyle.Render(fmt.Sprintf("%-*s", width+1, label)) + " " + f.value + "\n")
+	}
+	return sb.String()
+}
+
+// metadataPreview recognises executables and audio files by their magic
+// bytes. ok is false for formats without an extractor.
+func metadataPreview(vfs vfsHandler, path string, head []byte) (string, bool) {
+	switch {
+		case bytes.HasPrefix(head, []byte("\x7fELF")):
+			return executablePreview(vfs, path, elfInfo), true
+		case bytes.HasPrefix(head, []byte("MZ")):
+			return executablePreview(vfs, path, peInfo), true
+		case bytes.HasPrefix(head, []byte("ID3")):
+			return audioPreview(vfs, path, id3Tags), true
+		case bytes.HasPrefix(head, []byte("fLaC")):
+			return audioPreview(vfs, path, flacTags), true
+		case bytes.HasPrefix(head, []byte("OggS")):
+			return audioPreview(vfs, path, oggTags), true
+	}
+	return "", false
+}
+
+// openReaderAt gives random access to path, buffering files whose VFS cannot
+// seek.
+func openReaderAt(vfs vfsHandler, path string) (io.ReaderAt, func(), error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ra, ok := f.(io.ReaderAt); ok {
+		return ra, func() { f.Close() }, nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, metaMaxInMemory+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > metaMaxInMemory {
+		return nil, nil, fmt.Errorf("too large to inspect without random access")
+	}
+	return bytes.NewReader(data), func() {}, nil
+}
+
+// ─── Executables ──────────────────────────────────────────────────────────────
+
+func executablePreview(vfs vfsHandler, path string, info func(io.ReaderAt) (string, []metaField, error)) string {
+	ra, done, err := openReaderAt(vfs, path)
+	if err != nil {
+		return errorStyle.Render(fmt.Sprintf("Executable (unreadable): %v", err))
+	}
+	defer done()
+	title, fields, err := info(ra)
+	if err != nil {
+		return errorStyle.Render(fmt.Sprintf("%s: %v", title, err))
+	}
+	out := renderMeta(title, fields)
+	if bi, err := buildinfo.Read(ra); err == nil {
+		out += "\n" + goBuildInfo(bi)
+	}
+	return out
+}
+
+var elfArch = map[elf.Machine]string{
+	elf.EM_X86_64:  "x86-64",
+	elf.EM_386:     "x86",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+	elf.EM_RISCV:   "riscv",
+	elf.EM_PPC64:   "ppc64",
+	elf.EM_S390:    "s390x",
+	elf.EM_MIPS:    "mips",
+}
+
+func elfInfo(ra io.ReaderAt) (string, []metaField, error) {
+	f, err := elf.NewFile(ra)
+	if err != nil {
+		return "ELF executable", nil, err
+	}
+	defer f.Close()
+	arch, ok := elfArch[f.Machine]
+	if !ok {
+		arch = f.Machine.String()
+	}
+	bits := "32-bit"
+	if f.Class == elf.ELFCLASS64 {
+		bits = "64-bit"
+	}
+	order := "little-endian"
+	if f.ByteOrder == binary.BigEndian {
+		order = "big-endian"
+	}
+	kind := strings.TrimPrefix(f.Type.String(), "ET_")
+	interp := ""
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_INTERP {
+			b := make([]byte, min(prog.Filesz, 4096))
+			if _, err := prog.ReadAt(b, 0); err == nil {
+				interp = strings.TrimRight(string(b), "\x00")
+			}
+		}
+	}
+	linking := "static"
+	if interp != "" || f.Section(".dynamic") != nil {
+		linking = "dynamic"
+	}
+	stripped := "yes"
+	if f.Section(".symtab") != nil {
+		stripped = "no"
+	}
+	libs, _ := f.ImportedLibraries()
+	fields := []metaField{
+		{"Architecture", fmt.Sprintf("%s (%s, %s)", arch, bits, order)},
+		{"Type", kind},
+		{"OS/ABI", strings.TrimPrefix(f.OSABI.String(), "ELFOSABI_")},
+		{"Entry", fmt.Sprintf("0x%x", f.Entry)},
+		{"Linking", linking},
+		{"Interpreter", interp},
+		{"Sections", fmt.Sprint(len(f.Sections))},
+		{"Stripped", stripped},
+	}
+	return "⚙  ELF executable", append(fields, libraryFields(libs)...), nil
+}
+
+var peArch = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_AMD64: "x86-64",
+	pe.IMAGE_FILE_MACHINE_I386:  "x86",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+}
+
+var peSubsystem = map[uint16]string{
+	pe.IMAGE_SUBSYSTEM_NATIVE:          "native",
+	pe.IMAGE_SUBSYSTEM_WINDOWS_GUI:     "Windows GUI",
+	pe.IMAGE_SUBSYSTEM_WINDOWS_CUI:     "Windows console",
+	pe.IMAGE_SUBSYSTEM_EFI_APPLICATION: "EFI application",
+}
+
+func peInfo(ra io.ReaderAt) (string, []metaField, error) {
+	f, err := pe.NewFile(ra)
+	if err != nil {
+		return "PE executable", nil, err
+	}
+	defer f.Close()
+	arch, ok := peArch[f.Machine]
+	if !ok {
+		arch = fmt.Sprintf("0x%04x", f.Machine)
+	}
+	kind := "EXE"
+	if f.Characteristics&pe.IMAGE_FILE_DLL != 0 {
+		kind = "DLL"
+	}
+	var sub uint16
+	var entry uint64
+	switch oh := f.OptionalHeader.(type) {
+		case *pe.OptionalHeader32:
+			sub, entry = oh.Subsystem, uint64(oh.AddressOfEntryPoint)
+		case *pe.OptionalHeader64:
+			sub, entry = oh.Subsystem, uint64(oh.AddressOfEntryPoint)
+	}
+	libs, _ := f.ImportedLibraries()
+	fields := []metaField{
+		{"Architecture", arch},
+		{"Type", kind},
+		{"Subsystem", peSubsystem[sub]},
+		{"Entry", fmt.Sprintf("0x%x", entry)},
+		{"Sections", fmt.Sprint(len(f.Sections))},
+	}
+	return "⚙  PE executable", append(fields, libraryFields(libs)...), nil
+}
+
+func libraryFields(libs []string) []metaField {
+	if len(libs) == 0 {
+		return nil
+	}
+	sort.Strings(libs)
+	fields := []metaField{{"Libraries", libs[0]}}
+	for _, lib := range libs[1:] {
+		fields = append(fields, metaField{"", lib})
+	}
+	return fields
+}
+
+func goBuildInfo(bi *buildinfo.BuildInfo) string {
+	fields := []metaField{
+		{"Go version", bi.GoVersion},
+		{"Path", bi.Path},
+		{"Module", strings.TrimSpace(bi.Main.Path + " " + bi.Main.Version)},
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+			case "GOOS", "GOARCH", "CGO_ENABLED", "vcs.revision", "vcs.time", "vcs.modified", "-tags", "-ldflags":
+				fields = append(fields, metaField{s.Key, s.Value})
+		}
+	}
+	if len(bi.Deps) > 0 {
+		fields = append(fields, metaField{"Dependencies", fmt.Sprint(len(bi.Deps))})
+	}
+	return renderMeta("Go build info", fields)
+}
+
+// ─── Audio tags ───────────────────────────────────────────────────────────────
+
+// audioMaxTagBytes bounds how much of a file is read looking for tags.
+const audioMaxTagBytes = 1024 * 1024
+
+func audioPreview(vfs vfsHandler, path string, tags func(io.Reader) (string, []metaField, error)) string {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return errorStyle.Render(fmt.Sprintf("Audio file (unreadable): %v", err))
+	}
+	defer f.Close()
+	title, fields, err := tags(io.LimitReader(f, audioMaxTagBytes))
+	if err != nil && len(fields) == 0 {
+		return errorStyle.Render(fmt.Sprintf("%s: %v", title, err))
+	}
+	return renderMeta(title, fields)
+}
+
+// audioTagOrder puts the common Vorbis comment fields first.
+var audioTagOrder = []string{"TITLE", "ARTIST", "ALBUM", "ALBUMARTIST", "DATE", "TRACKNUMBER", "DISCNUMBER", "GENRE", "COMPOSER"}
+
+// vorbisFields turns KEY=value comments into fields, well-known keys first.
+func vorbisFields(comments []string) []metaField {
+	values := make(map[string][]string)
+	var keys []string
+	for _, c := range comments {
+		k, v, ok := strings.Cut(c, "=")
+		if !ok {
+			continue
+		}
+		k = strings.ToUpper(k)
+		if k == "METADATA_BLOCK_PICTURE" {
+			v = "(embedded picture)"
+		}
+		if _, seen := values[k]; !seen {
+			keys = append(keys, k)
+		}
+		values[k] = append(values[k], v)
+	}
+	rank := func(k string) int {
+		for i, o := range audioTagOrder {
+			if o == k {
+				return i
+			}
+		}
+		return len(audioTagOrder)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return rank(keys[i]) < rank(keys[j]) })
+	fields := make([]metaField, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, metaField{tagLabel(k), clipValue(strings.Join(values[k], "; "))})
+	}
+	return fields
+}
+
+func tagLabel(key string) string {
+	if key == "" {
+		return key
+	}
+	return key[:1] + strings.ToLower(key[1:])
+}
+
+func clipValue(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r := []rune(s); len(r) > 80 {
+		return string(r[:79]) + "…"
+	}
+	return s
+}
+
+// readVorbisComments parses the comment list shared by Vorbis, Opus and FLAC.
+func readVorbisComments(b []byte) (vendor string, comments []string, err error) {
+	next := func() (string, bool) {
+		if len(b) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return "", false
+		}
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+	vendor, ok := next()
+	if !ok || len(b) < 4 {
+		return "", nil, fmt.Errorf("truncated comment header")
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	for i := uint32(0); i < count; i++ {
+		c, ok := next()
+		if !ok {
+			return vendor, comments, fmt.Errorf("truncated comment header")
+		}
+		comments = append(comments, c)
+	}
+	return vendor, comments, nil
+}
+
+// id3Frames maps ID3v2.3/2.4 and v2.2 frame IDs to labels.
+var id3Frames = map[string]string{
+	"TIT2": "Title", "TT2": "Title",
+	"TPE1": "Artist", "TP1": "Artist",
+	"TPE2": "Album artist", "TP2": "Album artist",
+	"TALB": "Album", "TAL": "Album",
+	"TYER": "Year", "TYE": "Year", "TDRC": "Year",
+	"TRCK": "Track", "TRK": "Track",
+	"TPOS": "Disc", "TPA": "Disc",
+	"TCON": "Genre", "TCO": "Genre",
+	"TCOM": "Composer", "TCM": "Composer",
+	"TBPM": "BPM", "TBP": "BPM",
+	"COMM": "Comment", "COM": "Comment",
+	"APIC": "Cover", "PIC": "Cover",
+}
+
+var id3Order = []string{"Title", "Artist", "Album artist", "Album", "Year", "Track", "Disc", "Genre", "Composer", "BPM", "Comment", "Cover"}
+
+func id3Tags(r io.Reader) (string, []metaField, error) {
+	hdr := make([]byte, 10)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return "♪ MP3", nil, err
+	}
+	ver, flags := hdr[3], hdr[5]
+	title := fmt.Sprintf("♪ MP3 · ID3v2.%d", ver)
+	// The size comes from the file; read at most audioMaxTagBytes of it.
+	want := int64(min(syncsafe(hdr[6:10]), audioMaxTagBytes))
+	tag, err := io.ReadAll(io.LimitReader(r, want))
+	if err == nil && int64(len(tag)) < want {
+		err = io.ErrUnexpectedEOF
+	}
+	if flags&0x80 != 0 {
+		tag = bytes.ReplaceAll(tag, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+	if flags&0x40 != 0 && ver >= 3 && len(tag) >= 4 {
+		// Skip the extended header.
+		ext := int(binary.BigEndian.Uint32(tag))
+		if ver == 4 {
+			ext = syncsafe(tag[:4])
+		} else {
+			ext += 4
+		}
+		if ext <= len(tag) {
+			tag = tag[ext:]
+		}
+	}
+
+	idLen, hdrLen := 4, 10
+	if ver == 2 {
+		idLen, hdrLen = 3, 6
+	}
+	found := make(map[string]string)
+	for len(tag) >= hdrLen && tag[0] != 0 {
+		id := string(tag[:idLen])
+		var size int
+		switch ver {
+			case 2:
+				size = int(tag[3])<<16 | int(tag[4])<<8 | int(tag[5])
+			case 3:
+				size = int(binary.BigEndian.Uint32(tag[4:8]))
+			default:
+				size = syncsafe(tag[4:8])
+		}
+		if size < 0 || size > len(tag)-hdrLen {
+			break
+		}
+		body := tag[hdrLen : hdrLen+size]
+		tag = tag[hdrLen+size:]
+		label, ok := id3Frames[id]
+		if !ok || len(body) == 0 || found[label] != "" {
+			continue
+		}
+		switch {
+			case id == "APIC" || id == "PIC":
+				found[label] = fmt.Sprintf("embedded picture (%s)", humanSize(int64(size)))
+			case id == "COMM" || id == "COM":
+				// encoding, language, short description, text
+				if len(body) > 4 {
+					parts := splitID3Text(body[0], body[4:])
+					if len(parts) > 1 {
+						found[label] = clipValue(parts[1])
+					}
+				}
+			default:
+				found[label] = clipValue(strings.Join(splitID3Text(body[0], body[1:]), "; "))
+		}
+	}
+	fields := make([]metaField, 0, len(id3Order))
+	for _, label := range id3Order {
+		fields = append(fields, metaField{label, found[label]})
+	}
+	if err != nil && len(found) == 0 {
+		return title, nil, err
+	}
+	return title, fields, nil
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// splitID3Text decodes an ID3 text frame and splits its NUL-separated values.
+func splitID3Text(enc byte, b []byte) []string {
+	var s string
+	switch enc {
+		case 1, 2:
+			if enc == 1 && len(b) >= 2 {
+				le := b[0] == 0xFF && b[1] == 0xFE
+				s = decodeUTF16(b, le)
+			} else {
+				s = decodeUTF16(b, false)
+			}
+		case 3:
+			s = string(b)
+		default:
+			runes := make([]rune, len(b))
+			for i, c := range b {
+				runes[i] = rune(c)
+			}
+			s = string(runes)
+	}
+	s = strings.TrimPrefix(s, "\ufeff")
+	var parts []string
+	for _, p := range strings.Split(s, "\x00") {
+		if p = strings.TrimPrefix(p, "\ufeff"); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func decodeUTF16(b []byte, le bool) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if le {
+			u = append(u, uint16(b[i])|uint16(b[i+1])<<8)
+		} else {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+func flacTags(r io.Reader) (string, []metaField, error) {
+	title := "♪ FLAC"
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return title, nil, err
+	}
+	var info, tags []metaField
+	pictures := 0
+	for {
+		hdr := make([]byte, 4)
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			break
+		}
+		last, kind := hdr[0]&0x80 != 0, hdr[0]&0x7F
+		size := int64(hdr[1])<<16 | int64(hdr[2])<<8 | int64(hdr[3])
+		switch kind {
+			case 0, 4:
+				body := make([]byte, size)
+				if _, err := io.ReadFull(r, body); err != nil {
+					return title, append(info, tags...), err
+				}
+				if kind == 0 && len(body) >= 18 {
+					rate := int(body[10])<<12 | int(body[11])<<4 | int(body[12])>>4
+					channels := int(body[12]>>1&0x07) + 1
+					bps := int(body[12]&0x01)<<4 | int(body[13]>>4) + 1
+					samples := int64(body[13]&0x0F)<<32 | int64(binary.BigEndian.Uint32(body[14:18]))
+					info = streamFields(rate, channels, samples)
+					info = append(info, metaField{"Bit depth", fmt.Sprintf("%d-bit", bps)})
+				} else if kind == 4 {
+					vendor, comments, _ := readVorbisComments(body)
+					tags = append(vorbisFields(comments), metaField{"Encoder", vendor})
+				}
+			default:
+				if kind == 6 {
+					pictures++
+				}
+				if _, err := io.CopyN(io.Discard, r, size); err != nil {
+					last = true
+				}
+		}
+		if last {
+			break
+		}
+	}
+	if pictures > 0 {
+		tags = append(tags, metaField{"Pictures", fmt.Sprint(pictures)})
+	}
+	return title, append(info, tags...), nil
+}
+
+func streamFields(rate, channels int, samples int64) []metaField {
+	fields := []metaField{
+		{"Sample rate", fmt.Sprintf("%d Hz", rate)},
+		{"Channels", fmt.Sprint(channels)},
+	}
+	if rate > 0 && samples > 0 {
+		secs := int(math.Round(float64(samples) / float64(rate)))
+		fields = append(fields, metaField{"Duration", fmt.Sprintf("%d:%02d", secs/60, secs%60)})
+	}
+	return fields
+}
+
+// oggTags reads the identification and comment packets of the first
+// logical stream of an Ogg Vorbis or Opus file.
+func oggTags(r io.Reader) (string, []metaField, error) {
+	title := "♪ Ogg"
+	var packets [][]byte
+	var cur []byte
+	serial := uint32(0)
+	first := true
+	for len(packets) < 2 {
+		hdr := make([]byte, 27)
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return title, nil, err
+		}
+		if string(hdr[:4]) != "OggS" {
+			return title, nil, fmt.Errorf("bad Ogg page")
+		}
+		segs := make([]byte, hdr[26])
+		if _, err := io.ReadFull(r, segs); err != nil {
+			return title, nil, err
+		}
+		total := 0
+		for _, s := range segs {
+			total += int(s)
+		}
+		body := make([]byte, total)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return title, nil, err
+		}
+		ps := binary.LittleEndian.Uint32(hdr[14:18])
+		if first {
+			serial, first = ps, false
+		}
+		if ps != serial {
+			continue
+		}
+		for _, s := range segs {
+			cur = append(cur, body[:s]...)
+			body = body[s:]
+			if s < 255 {
+				packets = append(packets, cur)
+				cur = nil
+			}
+		}
+	}
+
+	var info []metaField
+	var comments []byte
+	id, tags := packets[0], packets[1]
+	switch {
+		case bytes.HasPrefix(id, []byte("\x01vorbis")) && len(id) >= 16:
+			title = "♪ Ogg Vorbis"
+			info = streamFields(int(binary.LittleEndian.Uint32(id[12:16])), int(id[11]), 0)
+			comments = bytes.TrimPrefix(tags, []byte("\x03vorbis"))
+		case bytes.HasPrefix(id, []byte("OpusHead")) && len(id) >= 16:
+			title = "♪ Ogg Opus"
+			info = streamFields(int(binary.LittleEndian.Uint32(id[12:16])), int(id[9]), 0)
+			comments = bytes.TrimPrefix(tags, []byte("OpusTags"))
+		default:
+			return title, []metaField{{"Codec", "unknown"}}, nil
+	}
+	vendor, list, err := readVorbisComments(comments)
+	fields := append(info, vorbisFields(list)...)
+	fields = append(fields, metaField{"Encoder", vendor})
+	return title, fields, err
+}
+
+// ─── EXIF ─────────────────────────────────────────────────────────────────────
+
+// exifMaxBytes bounds how much of a JPEG is scanned for the APP1 segment.
+const exifMaxBytes = 256 * 1024
+
+// exifPreview returns the interesting EXIF fields of a JPEG, or "".
+func exifPreview(vfs vfsHandler, path string) string {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(io.LimitReader(f, exifMaxBytes))
+	tiff := findExif(data)
+	if tiff == nil {
+		return ""
+	}
+	x := exifReader{data: tiff}
+	switch string(tiff[:2]) {
+		case "II":
+			x.order = binary.LittleEndian
+		case "MM":
+			x.order = binary.BigEndian
+		default:
+			return ""
+	}
+	ifd0 := x.ifd(int(x.order.Uint32(tiff[4:8])))
+	exif := x.ifd(x.int(ifd0[0x8769]))
+	gps := x.ifd(x.int(ifd0[0x8825]))
+
+	camera := x.str(ifd0[0x0110])
+	if mk := x.str(ifd0[0x010F]); mk != "" && !strings.HasPrefix(strings.ToLower(camera), strings.ToLower(mk)) {
+		camera = strings.TrimSpace(mk + " " + camera)
+	}
+	date := x.str(exif[0x9003])
+	if date == "" {
+		date = x.str(ifd0[0x0132])
+	}
+	dims := ""
+	if w, h := x.int(exif[0xA002]), x.int(exif[0xA003]); w > 0 && h > 0 {
+		dims = fmt.Sprintf("%d×%d", w, h)
+	}
+	exposure := ""
+	if n, d := x.rational(exif[0x829A]); d != 0 {
+		if n < d && n != 0 {
+			exposure = fmt.Sprintf("1/%.0f s", float64(d)/float64(n))
+		} else {
+			exposure = fmt.Sprintf("%g s", float64(n)/float64(d))
+		}
+	}
+	aperture := ""
+	if n, d := x.rational(exif[0x829D]); d != 0 {
+		aperture = fmt.Sprintf("f/%.1f", float64(n)/float64(d))
+	}
+	focal := ""
+	if n, d := x.rational(exif[0x920A]); d != 0 {
+		focal = fmt.Sprintf("%.0f mm", float64(n)/float64(d))
+	}
+	iso := ""
+	if v := x.int(exif[0x8827]); v > 0 {
+		iso = fmt.Sprint(v)
+	}
+	fields := []metaField{
+		{"Camera", camera},
+		{"Lens", x.str(exif[0xA434])},
+		{"Date", date},
+		{"Dimensions", dims},
+		{"Exposure", exposure},
+		{"Aperture", aperture},
+		{"ISO", iso},
+		{"Focal length", focal},
+		{"Software", x.str(ifd0[0x0131])},
+		{"GPS", x.gps(gps)},
+	}
+	empty := true
+	for _, f := range fields {
+		if f.value != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return ""
+	}
+	return renderMeta("EXIF", fields)
+}
+
+// findExif walks the JPEG markers up to the first scan and returns the TIFF
+// payload of the Exif APP1 segment.
+func findExif(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			return nil
+		}
+		seg := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) && len(seg) >= 14 {
+			return seg[6:]
+		}
+		i = end
+	}
+	return nil
+}
+
+// exifEntry is a raw IFD entry: its type, count and value bytes.
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	val   []byte
+}
+
+type exifReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+var exifTypeSize = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func (x exifReader) ifd(off int) map[uint16]exifEntry {
+	out := make(map[uint16]exifEntry)
+	if off <= 0 || off+2 > len(x.data) {
+		return out
+	}
+	n := int(x.order.Uint16(x.data[off:]))
+	for i := 0; i < n; i++ {
+		e := off + 2 + i*12
+		if e+12 > len(x.data) {
+			break
+		}
+		tag := x.order.Uint16(x.data[e:])
+		typ := x.order.Uint16(x.data[e+2:])
+		count := x.order.Uint32(x.data[e+4:])
+		size := exifTypeSize[typ] * int(count)
+		if size <= 0 || size > len(x.data) {
+			continue
+		}
+		val := x.data[e+8 : e+12]
+		if size > 4 {
+			p := int(x.order.Uint32(val))
+			if p < 0 || p+size > len(x.data) {
+				continue
+			}
+			val = x.data[p : p+size]
+		}
+		out[tag] = exifEntry{typ, count, val[:min(size, len(val))]}
+	}
+	return out
+}
+
+func (x exifReader) str(e exifEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.val), "\x00"))
+}
+
+func (x exifReader) int(e exifEntry) int {
+	switch {
+		case e.typ == 3 && len(e.val) >= 2:
+			return int(x.order.Uint16(e.val))
+		case (e.typ == 4 || e.typ == 9) && len(e.val) >= 4:
+			return int(x.order.Uint32(e.val))
+	}
+	return 0
+}
+
+func (x exifReader) rationalAt(e exifEntry, i int) (uint32, uint32) {
+	if (e.typ != 5 && e.typ != 10) || len(e.val) < (i+1)*8 {
+		return 0, 0
+	}
+	return x.order.Uint32(e.val[i*8:]), x.order.Uint32(e.val[i*8+4:])
+}
+
+func (x exifReader) rational(e exifEntry) (uint32, uint32) { return x.rationalAt(e, 0) }
+
+// gps formats latitude/longitude as signed decimal degrees.
+func (x exifReader) gps(g map[uint16]exifEntry) string {
+	coord := func(e exifEntry, ref string, neg string) (float64, bool) {
+		v := 0.0
+		for i, div := range []float64{1, 60, 3600} {
+			n, d := x.rationalAt(e, i)
+			if d == 0 {
+				return 0, false
+			}
+			v += float64(n) / float64(d) / div
+		}
+		if ref == neg {
+			v = -v
+		}
+		return v, true
+	}
+	lat, ok1 := coord(g[2], x.str(g[1]), "S")
+	lon, ok2 := coord(g[4], x.str(g[3]), "W")
+	if !ok1 || !ok2 {
+		return ""
+	}
+	out := fmt.Sprintf("%.6f, %.6f", lat, lon)
+	if n, d := x.rational(g[6]); d != 0 {
+		out += fmt.Sprintf(" · %.0f m", float64(n)/float64(d))
+	}
+	return out
+}
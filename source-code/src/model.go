@@ -1,13 +1,13 @@
 package src
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
-	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,6 +17,7 @@ import (
 type item struct {
 	title, desc string
 	status      string
+	mark        string // directory compare result
 	selected    bool
 	isDir       bool
 	size        int64
@@ -44,6 +45,9 @@ func (i item) Title() string {
 				title = gitDeletedStyle.Render(i.title)
 		}
 	}
+	if i.mark != "" {
+		title += " " + warnStyle.Render("["+i.mark+"]")
+	}
 	return prefix + title
 }
 
@@ -104,6 +108,33 @@ type panel struct {
 	preview       viewport.Model
 	vfs           vfsHandler
 	sortMode      SortMode
+
+	// terminal graphics escapes waiting to be written out-of-band
+	previewGraphics string
+
+	// asynchronous preview: the queued job, the running job's cancel func
+	// and a generation that invalidates stale results
+	previewJob    *previewJob
+	previewCancel context.CancelFunc
+	previewGen    int
+
+	// directory compare marks by name, valid while currentDir == compareDir
+	compareDir   string
+	compareMarks map[string]string
+
+	// find results shown instead of currentDir's entries, nil normally
+	find *findListing
+
+	// inotify or polling watch on currentDir for live refresh; a change
+	// seen while a filter was typed is applied once typing ends
+	watch        *dirWatch
+	watchPending bool
+
+	// what fileList shows (listingKey and its directory) and the cursor
+	// name last seen in each listing this session
+	listedKey    string
+	listedDir    string
+	cursorMemory map[string]string
 }
 
 type Model struct {
@@ -114,8 +145,10 @@ type Model struct {
 	keys         keyMap
 	mode         mode
 
-	editor     textarea.Model
-	editorFile string
+	// open editor buffers
+	buffers      []editorBuffer
+	activeBuffer int
+	bufferCursor int
 
 	progress progress.Model
 	quitting bool
@@ -124,14 +157,65 @@ type Model struct {
 	ResultChan   chan CommandResult
 
 	fuzzyInput   textinput.Model
-	fuzzyResults []string
+	fuzzy        fuzzyFinder
 
 	bulkRenameFrom string
 	bulkRenameTo   string
 
 	// confirmation dialog
 	confirmMsg    string
-	confirmAction func()
+	confirmAction func(m *Model) tea.Cmd
+	confirmPrev   mode
+
+	// hex viewer/editor
+	hex hexEditor
+
+	// large-file pager
+	pager pager
+
+	// disk usage analyzer and the scans kept for revisits
+	du      diskUsage
+	duScans []duScan
+
+	// two-file diff viewer
+	diff diffView
+
+	// invalidates compare results when a newer compare starts
+	compareGen int
+
+	// the running find, superseded by a newer one
+	findGen    int
+	findCancel context.CancelFunc
+
+	// how often sftp and podman panels are listed for live refresh
+	pollInterval time.Duration
+
+	// background path/trigram indexes of configured roots, nil if none
+	indexer *indexer
+
+	// content search and its results list
+	grep grepSearch
+
+	// search-and-replace preview and the last applied batch for undo
+	replace        *replacePlan
+	replaceGen     int
+	replaceUndo    []replaceBackup
+	replaceUndoVFS vfsHandler
+
+	// sync dry run awaiting confirmation
+	sync    *syncPlan
+	syncGen int
+
+	// external programs by extension/MIME/glob
+	openers []opener
+
+	imageProto   imageProtocol
+	previewCache *previewCache
+
+	// preview pane: keys scroll the active preview; quick view shows it in
+	// place of the inactive panel
+	previewFocus bool
+	quickView    bool
 
 	// podman browser
 	podmanContainers []string
@@ -179,10 +263,6 @@ func InitialModel() Model {
 	l2.SetShowFilter(true)
 	l2.SetFilteringEnabled(true)
 
-	ta := textarea.New()
-	ta.Placeholder = "Edit your file here…"
-	ta.Focus()
-
 	pv1 := viewport.New(0, 0)
 	pv1.SetContent("Select a file to preview")
 	pv2 := viewport.New(0, 0)
@@ -205,15 +285,25 @@ func InitialModel() Model {
 		commandInput: ti,
 		keys:         newKeyMap(),
 		mode:         explorerMode,
-		editor:       ta,
 		progress:     prog,
 		ProgressChan: make(chan ProgressMsg, 10),
 		ResultChan:   make(chan CommandResult, 10),
 		fuzzyInput:   fi,
+		imageProto:   detectImageProtocol(),
+		previewCache: newPreviewCache(),
+		pollInterval: pollIntervalFromEnv(),
 	}
 	for i := range m.panels {
 		m.refreshPanel(i)
 	}
+	openers, err := loadOpeners()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("openers: " + err.Error())
+	}
+	m.openers = openers
+	if m.indexer, err = startIndexer(); err != nil {
+		m.statusMsg = errorStyle.Render("index: " + err.Error())
+	}
 	return m
 }
 
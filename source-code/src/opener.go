@@ -0,0 +1,265 @@
+package src
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Openers ──────────────────────────────────────────────────────────────────
+
+// opener is one rule of the opener table, loaded from
+// $XDG_CONFIG_HOME/ngt/openers.json. The first matching rule wins.
+//
+//	[
+//	  {"ext": ".pdf", "command": "xdg-open {}", "detach": true},
+//	  {"mime": "image/*", "command": "xdg-open", "detach": true},
+//	  {"glob": "*.log", "command": "less"},
+//	  {"glob": "*", "command": "$EDITOR"}
+//	]
+//
+// command is split on whitespace after expanding environment variables;
+// "{}" is replaced by the file path, otherwise the path is appended.
+// The built-in tools hedit, hexedit and less run inside ngt.
+type opener struct {
+	Ext     string `json:"ext,omitempty"`
+	Mime    string `json:"mime,omitempty"`
+	Glob    string `json:"glob,omitempty"`
+	Command string `json:"command"`
+	Detach  bool   `json:"detach,omitempty"` // GUI apps: don't suspend the TUI
+}
+
+// openerDoneMsg is sent when an external program opened on a file exits.
+type openerDoneMsg struct {
+	err     error
+	vfs     vfsHandler
+	remote  string // path on vfs when tmp is a downloaded copy
+	tmp     string
+	tmpDir  string
+	before  [32]byte
+	program string
+}
+
+var envDefaults = map[string]string{
+	"EDITOR": "vi",
+	"VISUAL": "vi",
+	"PAGER":  "less",
+}
+
+func openersPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, "openers.json")
+}
+
+// loadOpeners reads the opener table; a missing file means no rules.
+func loadOpeners() ([]opener, error) {
+	path := openersPath()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []opener
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// matches reports whether rule applies; mime is resolved lazily.
+func (o opener) matches(name string, mime func() string) bool {
+	if o.Ext != "" && !strings.EqualFold(filepath.Ext(name), o.Ext) {
+		return false
+	}
+	if o.Glob != "" {
+		if ok, _ := filepath.Match(o.Glob, name); !ok {
+			return false
+		}
+	}
+	if o.Mime != "" {
+		if ok, _ := filepath.Match(o.Mime, mime()); !ok {
+			return false
+		}
+	}
+	return o.Ext != "" || o.Glob != "" || o.Mime != ""
+}
+
+func (m *Model) findOpener(vfs vfsHandler, path string) (opener, bool) {
+	var mimeType string
+	mime := func() string {
+		if mimeType == "" {
+			mimeType = detectMime(vfs, path)
+		}
+		return mimeType
+	}
+	for _, o := range m.openers {
+		if o.matches(filepath.Base(path), mime) {
+			return o, true
+		}
+	}
+	return opener{}, false
+}
+
+func detectMime(vfs vfsHandler, path string) string {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	return strings.SplitN(http.DetectContentType(buf[:n]), ";", 2)[0]
+}
+
+// openFile opens name (relative to the active panel) with the first matching
+// opener, falling back to the built-in editor.
+func (m *Model) openFile(name string) tea.Cmd {
+	p := &m.panels[m.activePanel]
+	path := filepath.Join(p.currentDir, name)
+	o, ok := m.findOpener(p.vfs, path)
+	if !ok {
+		return m.executeCommand("hedit " + name)
+	}
+	return m.runOpener(o.Command, o.Detach, path)
+}
+
+// runOpener launches command on path. Remote files are downloaded to a
+// temporary file first and uploaded back if the program changed them.
+func (m *Model) runOpener(command string, detach bool, path string) tea.Cmd {
+	p := &m.panels[m.activePanel]
+	args := strings.Fields(os.Expand(command, func(k string) string {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+		return envDefaults[k]
+	}))
+	if len(args) == 0 {
+		m.statusMsg = errorStyle.Render("open: empty command")
+		return nil
+	}
+	switch args[0] {
+		case "hedit", "hexedit", "less":
+			return m.executeCommand(args[0] + " " + filepath.Base(path))
+	}
+
+	done := openerDoneMsg{program: args[0]}
+	target := path
+	if _, ok := p.vfs.(localVFS); !ok {
+		dir, err := os.MkdirTemp("", "ngt-open-")
+		if err != nil {
+			m.statusMsg = errorStyle.Render(fmt.Sprintf("open: %v", err))
+			return nil
+		}
+		target = filepath.Join(dir, filepath.Base(path))
+		if err := copyFileVFS(p.vfs, localVFS{}, path, target, func(float64) {}); err != nil {
+			os.RemoveAll(dir)
+			m.statusMsg = errorStyle.Render(fmt.Sprintf("open: download: %v", err))
+			return nil
+		}
+		done.vfs, done.remote, done.tmp, done.tmpDir = p.vfs, path, target, dir
+		done.before, _ = fileChecksum(target)
+	}
+
+	placed := false
+	for i, a := range args {
+		if strings.Contains(a, "{}") {
+			args[i] = strings.ReplaceAll(a, "{}", target)
+			placed = true
+		}
+	}
+	if !placed {
+		args = append(args, target)
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Dir = filepath.Dir(target)
+	if detach {
+		// The remote copy is left behind for the GUI app to use.
+		if err := c.Start(); err != nil {
+			m.statusMsg = errorStyle.Render(fmt.Sprintf("open: %v", err))
+			return nil
+		}
+		go c.Wait()
+		m.statusMsg = successStyle.Render(fmt.Sprintf("Opened %s with %s", filepath.Base(path), args[0]))
+		return nil
+	}
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		done.err = err
+		return done
+	})
+}
+
+func fileChecksum(path string) ([32]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return [32]byte{}, err
+	}
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// finishOpener uploads a changed temp copy back to its VFS and cleans up.
+// The copy is uploaded even when the program exited non-zero, as many
+// editors do after :cq or a warning, and kept when the upload fails so the
+// edits are not lost.
+func (m *Model) finishOpener(msg openerDoneMsg) {
+	defer m.refreshPanel(m.activePanel)
+	failed := ""
+	if msg.err != nil {
+		failed = fmt.Sprintf("%s: %v", msg.program, msg.err)
+	}
+	if msg.tmp == "" {
+		m.statusMsg = ""
+		if failed != "" {
+			m.statusMsg = errorStyle.Render(failed)
+		}
+		return
+	}
+	after, err := fileChecksum(msg.tmp)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("open: %v (temp copy kept in %s)", err, msg.tmpDir))
+		return
+	}
+	if bytes.Equal(after[:], msg.before[:]) {
+		os.RemoveAll(msg.tmpDir)
+		if failed != "" {
+			m.statusMsg = errorStyle.Render(failed)
+			return
+		}
+		m.statusMsg = warnStyle.Render("No changes to upload")
+		return
+	}
+	if err := copyFileVFS(localVFS{}, msg.vfs, msg.tmp, msg.remote, func(float64) {}); err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("upload %s: %v (edited copy kept in %s)", filepath.Base(msg.remote), err, msg.tmp))
+		return
+	}
+	os.RemoveAll(msg.tmpDir)
+	if failed != "" {
+		m.statusMsg = warnStyle.Render("Uploaded " + msg.vfs.VFSName() + msg.remote + " (" + failed + ")")
+		return
+	}
+	m.statusMsg = successStyle.Render("Uploaded " + msg.vfs.VFSName() + msg.remote)
+}
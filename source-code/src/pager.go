@@ -0,0 +1,663 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Pager (read-only, streams large files) ───────────────────────────────────
+
+const (
+	pagerChunk      = 4 << 20   // bytes scanned per index/search step
+	pagerWindow     = 256 << 10 // max bytes read to fill one screen
+	pagerCheckpoint = 1024      // lines between index checkpoints
+	pagerMaxInMem   = 256 << 20 // cap for VFSes without random access
+)
+
+type pagerPrompt int
+
+const (
+	pagerPromptNone pagerPrompt = iota
+	pagerPromptGoto
+	pagerPromptSearch
+	pagerPromptSearchBack
+)
+
+// pager shows a file without loading it. Only the visible window is read;
+// a sparse line index (one offset every pagerCheckpoint lines) is built in
+// the background so jump-to-line stays cheap on multi-GB files.
+type pager struct {
+	file string
+	f    fs.File
+	ra   io.ReaderAt
+	size int64
+	gen  int
+
+	checkpoints []int64
+	lineCount   int64
+	scanned     int64
+	indexDone   bool
+
+	top     int64 // byte offset of the first visible line
+	topLine int64 // 0-based line number of top, -1 when unknown
+	xOff    int
+	width   int
+	height  int
+	lines   []string
+	atEOF   bool
+
+	follow    bool
+	canFollow bool
+	re        *regexp.Regexp
+	searching bool
+	prompt    pagerPrompt
+	input     textinput.Model
+}
+
+type pagerIndexMsg struct {
+	gen         int
+	checkpoints []int64
+	lines       int64
+	scanned     int64
+	done        bool
+}
+
+type pagerSearchMsg struct {
+	gen   int
+	off   int64
+	found bool
+	err   error
+}
+
+type pagerTickMsg struct{ gen int }
+
+var pagerGen int
+
+func (m *Model) openPager(file string) tea.Cmd {
+	p := &m.panels[m.activePanel]
+	f, err := p.vfs.Open(file)
+	if err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("less: %v", err))
+		return nil
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("less: %v", err))
+		return nil
+	}
+	ra, canFollow := f.(io.ReaderAt)
+	size := stat.Size()
+	if !canFollow {
+		// No random access (podman, archives): fall back to memory.
+		data, err := io.ReadAll(io.LimitReader(f, pagerMaxInMem))
+		f.Close()
+		if err != nil {
+			m.statusMsg = errorStyle.Render(fmt.Sprintf("less: %v", err))
+			return nil
+		}
+		f = nil
+		ra = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+	if m.pager.f != nil {
+		m.pager.f.Close()
+	}
+	pagerGen++
+	ti := textinput.New()
+	ti.Width = 40
+	w, h := m.editorSize()
+	m.pager = pager{
+		file:        file,
+		f:           f,
+		ra:          ra,
+		size:        size,
+		gen:         pagerGen,
+		checkpoints: []int64{0},
+		width:       w,
+		height:      h,
+		canFollow:   canFollow,
+		input:       ti,
+	}
+	m.pager.refresh()
+	m.commandInput.Blur()
+	m.mode = pagerMode
+	return m.pager.indexCmd()
+}
+
+func (m *Model) closePager() {
+	if m.pager.f != nil {
+		m.pager.f.Close()
+	}
+	m.pager = pager{}
+	m.mode = explorerMode
+	m.commandInput.Focus()
+}
+
+// readAt returns up to n bytes at off, tolerating EOF.
+func (p *pager) readAt(off int64, n int) []byte {
+	if off >= p.size || n <= 0 {
+		return nil
+	}
+	if rem := p.size - off; int64(n) > rem {
+		n = int(rem)
+	}
+	buf := make([]byte, n)
+	got, _ := p.ra.ReadAt(buf, off)
+	return buf[:got]
+}
+
+// nextLine returns the offset just past the newline ending the line at off.
+func (p *pager) nextLine(off int64) int64 {
+	for off < p.size {
+		buf := p.readAt(off, 8<<10)
+		if len(buf) == 0 {
+			break
+		}
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			return off + int64(i) + 1
+		}
+		off += int64(len(buf))
+	}
+	return p.size
+}
+
+// lineStart returns the start of the line containing the byte before end.
+func (p *pager) lineStart(end int64) int64 {
+	for end > 0 {
+		start := end - 8<<10
+		if start < 0 {
+			start = 0
+		}
+		buf := p.readAt(start, int(end-start))
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			return start + int64(i) + 1
+		}
+		end = start
+	}
+	return 0
+}
+
+func (p *pager) prevLine(off int64) int64 {
+	if off <= 0 {
+		return 0
+	}
+	return p.lineStart(off - 1)
+}
+
+// refresh re-reads the visible window starting at p.top.
+func (p *pager) refresh() {
+	buf := p.readAt(p.top, pagerWindow)
+	end := p.top+int64(len(buf)) >= p.size
+	p.lines = p.lines[:0]
+	for len(p.lines) < p.height && len(buf) > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			p.lines = append(p.lines, string(buf))
+			buf = nil
+			break
+		}
+		p.lines = append(p.lines, string(buf[:i]))
+		buf = buf[i+1:]
+	}
+	p.atEOF = end && len(buf) == 0
+}
+
+func (p *pager) scrollDown(n int) {
+	if p.atEOF {
+		return
+	}
+	for i := 0; i < n; i++ {
+		next := p.nextLine(p.top)
+		if next >= p.size {
+			break
+		}
+		p.top = next
+		if p.topLine >= 0 {
+			p.topLine++
+		}
+	}
+	p.refresh()
+	if len(p.lines) < p.height && p.top > 0 {
+		// never scroll past the last full screen
+		p.toEnd()
+	}
+}
+
+func (p *pager) scrollUp(n int) {
+	for i := 0; i < n && p.top > 0; i++ {
+		p.top = p.prevLine(p.top)
+		if p.topLine > 0 {
+			p.topLine--
+		}
+	}
+	p.refresh()
+}
+
+func (p *pager) toTop() {
+	p.top, p.topLine = 0, 0
+	p.refresh()
+}
+
+func (p *pager) toEnd() {
+	off := p.size
+	if off > 0 {
+		off = p.lineStart(off)
+		if off == p.size {
+			// trailing newline: last line is the one before it
+			off = p.prevLine(off)
+		}
+	}
+	for i := 1; i < p.height && off > 0; i++ {
+		off = p.prevLine(off)
+	}
+	p.jumpTo(off)
+}
+
+// jumpTo moves to the line containing off and resolves its line number.
+func (p *pager) jumpTo(off int64) {
+	if off > 0 {
+		off = p.lineStart(off)
+	}
+	p.top = off
+	p.topLine = p.lineNumberAt(off)
+	p.refresh()
+}
+
+// lineNumberAt counts lines from the nearest checkpoint, or -1 when the
+// indexer has not reached off yet.
+func (p *pager) lineNumberAt(off int64) int64 {
+	if off > p.scanned && !p.indexDone {
+		return -1
+	}
+	k := sort.Search(len(p.checkpoints), func(i int) bool { return p.checkpoints[i] > off }) - 1
+	if k < 0 {
+		k = 0
+	}
+	line := int64(k) * pagerCheckpoint
+	for pos := p.checkpoints[k]; pos < off; {
+		n := pagerWindow
+		if rem := off - pos; rem < int64(n) {
+			n = int(rem)
+		}
+		buf := p.readAt(pos, n)
+		if len(buf) == 0 {
+			break
+		}
+		line += int64(bytes.Count(buf, []byte{'\n'}))
+		pos += int64(len(buf))
+	}
+	return line
+}
+
+func (p *pager) gotoLine(n int64) error {
+	if n < 0 {
+		n = 0
+	}
+	k := n / pagerCheckpoint
+	if k >= int64(len(p.checkpoints)) {
+		if !p.indexDone {
+			return fmt.Errorf("line %d not indexed yet (%d so far)", n+1, p.lineCount)
+		}
+		p.toEnd()
+		return nil
+	}
+	off := p.checkpoints[k]
+	for i := k * pagerCheckpoint; i < n; i++ {
+		next := p.nextLine(off)
+		if next >= p.size {
+			break
+		}
+		off = next
+	}
+	p.top, p.topLine = off, n
+	p.refresh()
+	return nil
+}
+
+// indexCmd scans the next chunk and records line checkpoints.
+func (p *pager) indexCmd() tea.Cmd {
+	ra, gen, start, lines := p.ra, p.gen, p.scanned, p.lineCount
+	return func() tea.Msg {
+		msg := pagerIndexMsg{gen: gen, lines: lines, scanned: start}
+		buf := make([]byte, pagerChunk)
+		n, err := ra.ReadAt(buf, start)
+		buf = buf[:n]
+		for i, b := range buf {
+			if b != '\n' {
+				continue
+			}
+			msg.lines++
+			if msg.lines%pagerCheckpoint == 0 {
+				msg.checkpoints = append(msg.checkpoints, start+int64(i)+1)
+			}
+		}
+		msg.scanned = start + int64(n)
+		msg.done = n == 0 || (err != nil && err != io.EOF)
+		return msg
+	}
+}
+
+// searchCmd looks for re line by line starting at from (forward) or ending
+// before from (backward) and reports the start of the matching line.
+func (p *pager) searchCmd(from int64, forward bool) tea.Cmd {
+	ra, gen, size, re := p.ra, p.gen, p.size, p.re
+	read := func(off int64, n int64) []byte {
+		buf := make([]byte, n)
+		got, _ := ra.ReadAt(buf, off)
+		return buf[:got]
+	}
+	return func() tea.Msg {
+		if forward {
+			for off := from; off < size; {
+				buf := read(off, pagerChunk)
+				if len(buf) == 0 {
+					break
+				}
+				if off+int64(len(buf)) < size {
+					if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+						buf = buf[:i+1]
+					}
+				}
+				if loc := re.FindIndex(buf); loc != nil {
+					ls := bytes.LastIndexByte(buf[:loc[0]], '\n') + 1
+					return pagerSearchMsg{gen: gen, off: off + int64(ls), found: true}
+				}
+				off += int64(len(buf))
+			}
+			return pagerSearchMsg{gen: gen}
+		}
+		for end := from; end > 0; {
+			start := end - pagerChunk
+			if start < 0 {
+				start = 0
+			}
+			buf := read(start, end-start)
+			skip := 0
+			if start > 0 {
+				if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+					skip = i + 1
+				}
+			}
+			if locs := re.FindAllIndex(buf[skip:], -1); len(locs) > 0 {
+				last := locs[len(locs)-1][0] + skip
+				ls := bytes.LastIndexByte(buf[:last], '\n') + 1
+				return pagerSearchMsg{gen: gen, off: start + int64(ls), found: true}
+			}
+			// The partial first line is searched with the next chunk; a
+			// chunk that holds nothing but one overlong line is passed by.
+			end = start + int64(skip)
+			if skip == 0 || skip == len(buf) {
+				end = start
+			}
+		}
+		return pagerSearchMsg{gen: gen}
+	}
+}
+
+func pagerTick(gen int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return pagerTickMsg{gen: gen} })
+}
+
+// compileSearch uses smart case: lower-case patterns match case-insensitively,
+// and anything that is not a valid regexp is searched literally.
+func compileSearch(pattern string) (*regexp.Regexp, error) {
+	prefix := "(?m)"
+	if strings.ToLower(pattern) == pattern {
+		prefix = "(?mi)"
+	}
+	re, err := regexp.Compile(prefix + pattern)
+	if err != nil {
+		return regexp.Compile(prefix + regexp.QuoteMeta(pattern))
+	}
+	return re, nil
+}
+
+// ─── Pager messages & keys ────────────────────────────────────────────────────
+
+func (m *Model) handlePagerMsg(msg tea.Msg) tea.Cmd {
+	p := &m.pager
+	switch msg := msg.(type) {
+		case pagerIndexMsg:
+			if msg.gen != p.gen || m.mode != pagerMode {
+				return nil
+			}
+			p.checkpoints = append(p.checkpoints, msg.checkpoints...)
+			p.lineCount = msg.lines
+			p.scanned = msg.scanned
+			p.indexDone = msg.done || p.scanned >= p.size
+			if p.topLine < 0 {
+				p.topLine = p.lineNumberAt(p.top)
+			}
+			if !p.indexDone {
+				return p.indexCmd()
+			}
+		case pagerSearchMsg:
+			if msg.gen != p.gen || m.mode != pagerMode {
+				return nil
+			}
+			p.searching = false
+			if !msg.found {
+				m.statusMsg = warnStyle.Render("Pattern not found: " + p.re.String())
+				return nil
+			}
+			p.follow = false
+			p.jumpTo(msg.off)
+			m.statusMsg = ""
+		case pagerTickMsg:
+			if msg.gen != p.gen || m.mode != pagerMode || !p.follow {
+				return nil
+			}
+			if stat, err := p.f.Stat(); err == nil && stat.Size() != p.size {
+				grew := stat.Size() > p.size
+				p.size = stat.Size()
+				if !grew {
+					// truncated (log rotation): drop in-flight work and reindex
+					pagerGen++
+					p.gen = pagerGen
+					p.checkpoints, p.lineCount, p.scanned = []int64{0}, 0, 0
+				}
+				p.toEnd()
+				if p.indexDone || !grew {
+					p.indexDone = false
+					return tea.Batch(p.indexCmd(), pagerTick(p.gen))
+				}
+			}
+			return pagerTick(p.gen)
+	}
+	return nil
+}
+
+func (m *Model) updatePager(msg tea.KeyMsg) tea.Cmd {
+	p := &m.pager
+	if p.prompt != pagerPromptNone {
+		switch msg.String() {
+			case "esc":
+				p.prompt = pagerPromptNone
+				p.input.Blur()
+			case "enter":
+				val := strings.TrimSpace(p.input.Value())
+				prompt := p.prompt
+				p.prompt = pagerPromptNone
+				p.input.Blur()
+				if val == "" {
+					return nil
+				}
+				if prompt == pagerPromptGoto {
+					if strings.HasSuffix(val, "%") {
+						pct, err := strconv.ParseFloat(strings.TrimSuffix(val, "%"), 64)
+						if err != nil {
+							m.statusMsg = errorStyle.Render("Invalid percentage: " + val)
+							return nil
+						}
+						p.follow = false
+						p.jumpTo(int64(float64(p.size) * pct / 100))
+						return nil
+					}
+					n, err := strconv.ParseInt(val, 10, 64)
+					if err != nil {
+						m.statusMsg = errorStyle.Render("Invalid line number: " + val)
+						return nil
+					}
+					p.follow = false
+					if err := p.gotoLine(n - 1); err != nil {
+						m.statusMsg = warnStyle.Render(err.Error())
+					}
+					return nil
+				}
+				re, err := compileSearch(val)
+				if err != nil {
+					m.statusMsg = errorStyle.Render("Invalid pattern: " + err.Error())
+					return nil
+				}
+				p.re = re
+				return m.pagerSearch(prompt == pagerPromptSearch)
+			default:
+				var cmd tea.Cmd
+				p.input, cmd = p.input.Update(msg)
+				return cmd
+		}
+		return nil
+	}
+
+	switch msg.String() {
+		case "q", "esc":
+			m.closePager()
+		case "j", "down", "enter":
+			p.scrollDown(1)
+		case "k", "up":
+			p.scrollUp(1)
+		case " ", "pgdown", "f":
+			p.scrollDown(p.height - 1)
+		case "b", "pgup":
+			p.scrollUp(p.height - 1)
+		case "d":
+			p.scrollDown(p.height / 2)
+		case "u":
+			p.scrollUp(p.height / 2)
+		case "g", "home":
+			p.follow = false
+			p.toTop()
+		case "G", "end":
+			p.toEnd()
+		case "h", "left":
+			if p.xOff >= 8 {
+				p.xOff -= 8
+			} else {
+				p.xOff = 0
+			}
+		case "l", "right":
+			p.xOff += 8
+		case ":":
+			return p.ask(pagerPromptGoto, "line number or NN%")
+		case "/":
+			return p.ask(pagerPromptSearch, "search forward (regexp)")
+		case "?":
+			return p.ask(pagerPromptSearchBack, "search backward (regexp)")
+		case "n":
+			return m.pagerSearch(true)
+		case "N":
+			return m.pagerSearch(false)
+		case "F":
+			if !p.canFollow {
+				m.statusMsg = warnStyle.Render("Follow mode is not supported on " + m.panels[m.activePanel].vfs.VFSName())
+				return nil
+			}
+			p.follow = !p.follow
+			if p.follow {
+				p.toEnd()
+				m.statusMsg = successStyle.Render("Following – press F to stop")
+				return pagerTick(p.gen)
+			}
+			m.statusMsg = ""
+	}
+	return nil
+}
+
+func (p *pager) ask(kind pagerPrompt, placeholder string) tea.Cmd {
+	p.prompt = kind
+	p.input.Reset()
+	p.input.Placeholder = placeholder
+	return p.input.Focus()
+}
+
+func (m *Model) pagerSearch(forward bool) tea.Cmd {
+	p := &m.pager
+	if p.re == nil {
+		m.statusMsg = warnStyle.Render("No search pattern (/ or ?)")
+		return nil
+	}
+	if p.searching {
+		return nil
+	}
+	p.searching = true
+	m.statusMsg = warnStyle.Render("Searching " + p.re.String() + "…")
+	if forward {
+		return p.searchCmd(p.nextLine(p.top), true)
+	}
+	return p.searchCmd(p.top, false)
+}
+
+// ─── Pager view ───────────────────────────────────────────────────────────────
+
+func (p *pager) view() string {
+	var sb strings.Builder
+	for i, line := range p.lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		line = strings.ReplaceAll(line, "\t", "    ")
+		line = strings.TrimSuffix(line, "\r")
+		runes := []rune(line)
+		if p.xOff < len(runes) {
+			runes = runes[p.xOff:]
+		} else {
+			runes = nil
+		}
+		if len(runes) > p.width {
+			runes = runes[:p.width]
+		}
+		line = string(runes)
+		if p.re != nil {
+			line = p.re.ReplaceAllStringFunc(line, func(s string) string { return pagerMatchStyle.Render(s) })
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+func (p *pager) statusLine() string {
+	pos := "line ?"
+	if p.topLine >= 0 {
+		pos = fmt.Sprintf("line %d", p.topLine+1)
+	}
+	total := fmt.Sprintf("%d+ lines (indexing %d%%)", p.lineCount, p.percentOf(p.scanned))
+	if p.indexDone {
+		total = fmt.Sprintf("%d lines", p.lineCount)
+	}
+	flags := ""
+	if p.follow {
+		flags += "  │  FOLLOW"
+	}
+	if p.searching {
+		flags += "  │  searching…"
+	}
+	return fmt.Sprintf("%s / %s  │  %d%%  │  %s%s", pos, total, p.percentOf(p.top), humanSize(p.size), flags)
+}
+
+func (p *pager) percentOf(off int64) int {
+	if p.size == 0 {
+		return 100
+	}
+	return int(off * 100 / p.size)
+}
@@ -2,9 +2,7 @@ package src
 
 import (
 	"fmt"
-	"io"
 	"io/fs"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,7 +10,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/charmbracelet/bubbles/list"
 )
 
@@ -20,7 +17,16 @@ import (
 
 func (m *Model) refreshPanel(idx int) {
 	p := &m.panels[idx]
-	files, err := p.vfs.ReadDir(p.currentDir)
+	if p.find != nil && (p.find.vfs != p.vfs || p.find.root != p.currentDir) {
+		p.find = nil
+	}
+	var files []fs.DirEntry
+	var err error
+	if p.find != nil {
+		files = p.find.restat()
+	} else {
+		files, err = p.vfs.ReadDir(p.currentDir)
+	}
 	if err != nil {
 		m.statusMsg = errorStyle.Render(fmt.Sprintf("ReadDir: %v", err))
 		return
@@ -45,17 +51,113 @@ func (m *Model) refreshPanel(idx int) {
 			title:    file.Name(),
 			       desc:     desc,
 			       status:   gitStatus[file.Name()],
+			       mark:     p.compareMark(file.Name()),
 			       isDir:    file.IsDir(),
 			       size:     info.Size(),
 			       modTime:  info.ModTime(),
 			       selected: p.selectedFiles[fp],
 		})
 	}
+	prevKey, prevDir, prevIdx := p.listedKey, p.listedDir, p.fileList.Index()
+	var prevNames []string
+	for _, it := range p.fileList.Items() {
+		prevNames = append(prevNames, it.(item).title)
+	}
+	if cur, ok := p.fileList.SelectedItem().(item); ok && prevKey != "" {
+		if p.cursorMemory == nil {
+			p.cursorMemory = make(map[string]string)
+		}
+		p.cursorMemory[prevKey] = cur.title
+	}
 	p.fileList.SetItems(items)
+	p.listedKey, p.listedDir = p.listingKey(), p.currentDir
+	p.restoreCursor(prevKey, prevDir, prevNames, prevIdx)
 	m.updatePreview(idx)
 	m.updateGitBranch(idx)
 }
 
+// listingKey identifies what the panel lists: backend, directory and any
+// find query.
+func (p *panel) listingKey() string {
+	key := p.vfs.VFSName() + ":" + p.currentDir
+	if p.find != nil {
+		key += "\x00find " + p.find.query
+	}
+	return key
+}
+
+// restoreCursor places the cursor after SetItems. Refreshing the same
+// listing keeps it on the same name, or the nearest surviving neighbour;
+// moving up lands on the directory just left; anywhere else it goes back
+// to where it was last time this session.
+func (p *panel) restoreCursor(prevKey, prevDir string, prevNames []string, prevIdx int) {
+	if p.fileList.FilterState() != list.Unfiltered {
+		return // indices refer to the filtered view
+	}
+	pos := make(map[string]int)
+	for i, it := range p.fileList.Items() {
+		pos[it.(item).title] = i
+	}
+	if prevKey == p.listedKey {
+		for d := 0; d < len(prevNames); d++ {
+			for _, i := range []int{prevIdx + d, prevIdx - d} {
+				if i < 0 || i >= len(prevNames) {
+					continue
+				}
+				if n, ok := pos[prevNames[i]]; ok {
+					p.fileList.Select(n)
+					return
+				}
+			}
+		}
+		p.fileList.Select(min(prevIdx, max(len(pos)-1, 0)))
+		return
+	}
+	if rel, err := filepath.Rel(p.currentDir, prevDir); err == nil && p.find == nil &&
+		rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) &&
+		strings.HasPrefix(prevKey, p.vfs.VFSName()+":") {
+		child, _, _ := strings.Cut(rel, string(filepath.Separator))
+		if n, ok := pos[child]; ok {
+			p.fileList.Select(n)
+			return
+		}
+	}
+	if n, ok := pos[p.cursorMemory[p.listedKey]]; ok {
+		p.fileList.Select(n)
+		return
+	}
+	p.fileList.Select(0)
+}
+
+// changeDir moves panel idx to dir, taken relative to its current directory,
+// and lists it.
+func (m *Model) changeDir(idx int, dir string) error {
+	p := &m.panels[idx]
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(p.currentDir, dir)
+	}
+	if err := p.vfs.Chdir(dir); err != nil {
+		return err
+	}
+	p.currentDir, _ = p.vfs.Getwd()
+	p.find = nil
+	m.refreshPanel(idx)
+	return nil
+}
+
+// selectName puts the cursor of panel idx on the entry called name.
+func (m *Model) selectName(idx int, name string) bool {
+	p := &m.panels[idx]
+	for i, it := range p.fileList.Items() {
+		if it.(item).title == name {
+			p.fileList.Select(i)
+			m.updatePreview(idx)
+			return true
+		}
+	}
+	return false
+}
+
 func sortFiles(files []fs.DirEntry, mode SortMode) {
 	sort.Slice(files, func(i, j int) bool {
 		a, b := files[i], files[j]
@@ -125,148 +227,6 @@ func (m *Model) updateGitBranch(idx int) {
 	p.gitBranch = strings.TrimSpace(string(output))
 }
 
-// ─── Preview ──────────────────────────────────────────────────────────────────
-
-func (m *Model) updatePreview(idx int) {
-	p := &m.panels[idx]
-	selected, ok := p.fileList.SelectedItem().(item)
-	if !ok {
-		p.preview.SetContent("")
-		return
-	}
-	if selected.isDir {
-		// Show directory listing summary
-		entries, err := p.vfs.ReadDir(filepath.Join(p.currentDir, selected.title))
-		if err != nil {
-			p.preview.SetContent("Directory (unreadable)")
-			return
-		}
-		dirs, files := 0, 0
-		for _, e := range entries {
-			if e.IsDir() {
-				dirs++
-			} else {
-				files++
-			}
-		}
-		p.preview.SetContent(fmt.Sprintf("📁 Directory\n\n%d subdirectories\n%d files", dirs, files))
-		return
-	}
-	filePath := filepath.Join(p.currentDir, selected.title)
-	f, err := p.vfs.Open(filePath)
-	if err != nil {
-		p.preview.SetContent("Error opening file")
-		return
-	}
-	defer f.Close()
-	stat, err := f.Stat()
-	if err != nil {
-		p.preview.SetContent("Error stat file")
-		return
-	}
-
-	// MIME detection
-	buf := make([]byte, 512)
-	_, err = f.Read(buf)
-	if err != nil && err != io.EOF {
-		p.preview.SetContent("Error reading file")
-		return
-	}
-	mimeType := http.DetectContentType(buf)
-	if mimeType == "application/octet-stream" {
-		if _, ok := p.vfs.(localVFS); ok {
-			cmd := exec.Command("file", "-b", "--mime-type", filePath)
-			out, err := cmd.Output()
-			if err == nil {
-				mimeType = strings.TrimSpace(string(out))
-			}
-		}
-	}
-
-	// Rewind
-	if s, ok := f.(io.Seeker); ok {
-		s.Seek(0, io.SeekStart)
-	} else {
-		f.Close()
-		f, err = p.vfs.Open(filePath)
-		if err != nil {
-			p.preview.SetContent("Error reopening file")
-			return
-		}
-		defer f.Close()
-	}
-
-	if strings.HasPrefix(mimeType, "image/") {
-		p.preview.SetContent(fmt.Sprintf("🖼  Image file\nMIME: %s\nSize: %s", mimeType, humanSize(stat.Size())))
-		return
-	}
-	if !strings.HasPrefix(mimeType, "text/") {
-		p.preview.SetContent(fmt.Sprintf("Binary file\nMIME: %s\nSize: %s", mimeType, humanSize(stat.Size())))
-		return
-	}
-	if stat.Size() > maxFileSizeForEdit {
-		p.preview.SetContent(fmt.Sprintf("File too large for preview\nSize: %s", humanSize(stat.Size())))
-		return
-	}
-
-	byteContent, err := io.ReadAll(f)
-	if err != nil {
-		p.preview.SetContent("Error reading file")
-		return
-	}
-	content := string(byteContent)
-	lines := strings.Split(content, "\n")
-	if len(lines) > previewLines {
-		lines = lines[:previewLines]
-		content = strings.Join(lines, "\n") + "\n\n… (truncated)"
-	}
-
-	// Syntax highlight
-	lexer := lexers.Match(selected.title)
-	if lexer == nil {
-		lexer = lexers.Fallback
-	}
-	iterator, err := lexer.Tokenise(nil, content)
-	if err != nil {
-		p.preview.SetContent(content)
-		return
-	}
-	var sb strings.Builder
-	if err = chromaFormatter.Format(&sb, chromaStyle, iterator); err != nil {
-		p.preview.SetContent(content)
-		return
-	}
-	p.preview.SetContent(sb.String())
-}
-
-// ─── Fuzzy search ─────────────────────────────────────────────────────────────
-
-func (m *Model) performFuzzySearch() {
-	query := m.fuzzyInput.Value()
-	m.fuzzyResults = nil
-	if query == "" {
-		m.panels[m.activePanel].fileList.SetItems(nil)
-		return
-	}
-	var results []string
-	_ = filepath.Walk(m.panels[m.activePanel].currentDir, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if strings.Contains(strings.ToLower(filepath.Base(path)), strings.ToLower(query)) {
-			rel, _ := filepath.Rel(m.panels[m.activePanel].currentDir, path)
-			results = append(results, rel)
-		}
-		return nil
-	})
-	m.fuzzyResults = results
-	var items []list.Item
-	for _, res := range results {
-		items = append(items, item{title: res})
-	}
-	m.panels[m.activePanel].fileList.SetItems(items)
-}
-
 // ─── Bulk rename ──────────────────────────────────────────────────────────────
 
 func (m *Model) performBulkRename() {
@@ -282,6 +242,9 @@ func (m *Model) performBulkRename() {
 		if err := os.Rename(file, newPath); err != nil {
 			m.statusMsg += errorStyle.Render(fmt.Sprintf(" %s: %v", filepath.Base(file), err))
 		} else {
+			if l := m.panels[m.activePanel].find; l != nil {
+				l.renamed(file, newPath)
+			}
 			renamed++
 		}
 	}
@@ -0,0 +1,326 @@
+package src
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ─── Preview ──────────────────────────────────────────────────────────────────
+
+const (
+	previewCacheSize = 256
+	previewDebounce  = 30 * time.Millisecond
+)
+
+// previewEnv is the snapshot of model state a preview job works from. Jobs
+// run off the UI goroutine and never touch the Model.
+type previewEnv struct {
+	vfs     vfsHandler
+	dir     string
+	it      item
+	width   int
+	height  int
+	proto   imageProtocol
+	originX int
+	originY int
+	visible bool
+}
+
+func (env previewEnv) path() string { return filepath.Join(env.dir, env.it.title) }
+
+// cacheKey identifies a rendering: the file version and the pane size.
+func (env previewEnv) cacheKey() string {
+	return fmt.Sprintf("%s%s|%d|%d|%dx%d", env.vfs.VFSName(), env.path(), env.it.modTime.UnixNano(), env.it.size, env.width, env.height)
+}
+
+// previewResult is a finished preview; graphics holds terminal image escapes
+// that are written out-of-band.
+type previewResult struct {
+	content  string
+	graphics string
+	// finish, when set, completes a partial result in the background
+	finish func(ctx context.Context) previewResult
+}
+
+// previewJob is a queued preview request for one panel.
+type previewJob struct {
+	env previewEnv
+	ctx context.Context
+	gen int
+}
+
+// previewMsg delivers a finished (or partial) preview job.
+type previewMsg struct {
+	panel int
+	job   *previewJob
+	res   previewResult
+}
+
+// ─── LRU cache ────────────────────────────────────────────────────────────────
+
+type previewCacheEntry struct {
+	key string
+	res previewResult
+}
+
+// previewCache keeps the most recently shown previews. It is only touched
+// from Update, so it needs no locking.
+type previewCache struct {
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newPreviewCache() *previewCache {
+	return &previewCache{order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *previewCache) get(key string) (previewResult, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return previewResult{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*previewCacheEntry).res, true
+}
+
+func (c *previewCache) put(key string, res previewResult) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*previewCacheEntry).res = res
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&previewCacheEntry{key, res})
+	for c.order.Len() > previewCacheSize {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*previewCacheEntry).key)
+	}
+}
+
+// ─── Scheduling ───────────────────────────────────────────────────────────────
+
+// updatePreview shows the preview for the selected item of panel idx, from
+// the cache when possible, otherwise by queueing a job that previewCmds
+// starts after the current Update. Any job still running is cancelled.
+func (m *Model) updatePreview(idx int) {
+	p := &m.panels[idx]
+	if p.previewCancel != nil {
+		p.previewCancel()
+		p.previewCancel = nil
+	}
+	p.previewGen++
+	p.previewJob = nil
+	selected, ok := p.fileList.SelectedItem().(item)
+	if !ok || p.preview.Width <= 0 || p.preview.Height <= 0 {
+		p.preview.SetContent("")
+		return
+	}
+	x, y, visible := m.previewOrigin(idx)
+	env := previewEnv{
+		vfs:     p.vfs,
+		dir:     p.currentDir,
+		it:      selected,
+		width:   p.preview.Width,
+		height:  p.preview.Height,
+		proto:   m.imageProto,
+		originX: x,
+		originY: y,
+		visible: visible,
+	}
+	if res, ok := m.previewCache.get(env.cacheKey()); ok {
+		p.preview.SetContent(res.content)
+		p.preview.GotoTop()
+		return
+	}
+	p.preview.SetContent(metaKeyStyle.Render("Loading " + selected.title + "…"))
+	ctx, cancel := context.WithCancel(context.Background())
+	p.previewCancel = cancel
+	p.previewJob = &previewJob{env: env, ctx: ctx, gen: p.previewGen}
+}
+
+// previewCmds turns queued preview jobs into commands. Jobs wait a moment
+// first so that holding a movement key does not start a read per row.
+func (m *Model) previewCmds() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.panels {
+		job := m.panels[i].previewJob
+		if job == nil {
+			continue
+		}
+		m.panels[i].previewJob = nil
+		cmds = append(cmds, job.run(i, previewDebounce, func(ctx context.Context) previewResult {
+			return buildPreview(ctx, job.env)
+		}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// run executes build for panel idx off the UI goroutine after delay.
+// Cancelled jobs produce no message.
+func (job *previewJob) run(idx int, delay time.Duration, build func(ctx context.Context) previewResult) tea.Cmd {
+	return func() tea.Msg {
+		select {
+			case <-job.ctx.Done():
+				return nil
+			case <-time.After(delay):
+		}
+		res := build(job.ctx)
+		if job.ctx.Err() != nil {
+			return nil
+		}
+		// Cut long lines instead of letting the viewport wrap them.
+		res.content = lipgloss.NewStyle().MaxWidth(job.env.width).Render(res.content)
+		return previewMsg{panel: idx, job: job, res: res}
+	}
+}
+
+// handlePreviewMsg shows a finished preview unless the cursor moved on, and
+// starts the background part of partial results.
+func (m *Model) handlePreviewMsg(msg previewMsg) tea.Cmd {
+	p := &m.panels[msg.panel]
+	if msg.job.gen != p.previewGen {
+		return nil
+	}
+	// SetContent keeps the scroll position while the rest arrives.
+	p.preview.SetContent(msg.res.content)
+	p.previewGraphics = msg.res.graphics
+	if msg.res.finish != nil {
+		return msg.job.run(msg.panel, 0, msg.res.finish)
+	}
+	p.previewCancel = nil
+	// Terminal images are bound to a transmission, so only text is reused.
+	if msg.res.graphics == "" {
+		m.previewCache.put(msg.job.env.cacheKey(), msg.res)
+	}
+	return nil
+}
+
+// ctxReader fails reads once the preview job is cancelled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(b)
+}
+
+// ─── Rendering ────────────────────────────────────────────────────────────────
+
+// buildPreview renders the preview for env. It runs in a command goroutine.
+func buildPreview(ctx context.Context, env previewEnv) previewResult {
+	text := func(s string) previewResult { return previewResult{content: s} }
+	selected, filePath := env.it, env.path()
+	if selected.isDir {
+		return dirPreview(ctx, env.vfs, filePath)
+	}
+	if _, ok := env.vfs.(localVFS); ok && isArchive(selected.title) {
+		summary, err := archiveSummary(filePath, selected.size)
+		if err != nil {
+			return text(fmt.Sprintf("📦 Archive (unreadable)\n%v", err))
+		}
+		return text(summary)
+	}
+	f, err := env.vfs.Open(filePath)
+	if err != nil {
+		return text("Error opening file")
+	}
+	defer f.Close()
+	size := selected.size
+	r := ctxReader{ctx, f}
+
+	// MIME detection: content sniffing, then the extension
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return text("Error reading file")
+	}
+	buf = buf[:n]
+	mimeType := http.DetectContentType(buf)
+	if mimeType == "application/octet-stream" {
+		if t := mime.TypeByExtension(filepath.Ext(selected.title)); t != "" {
+			mimeType = strings.SplitN(t, ";", 2)[0]
+		}
+	}
+	if ctx.Err() != nil {
+		return previewResult{}
+	}
+
+	if strings.HasPrefix(mimeType, "image/") {
+		img, format, err := decodePreviewImage(env.vfs, filePath)
+		if err != nil {
+			return text(fmt.Sprintf("🖼  Image file\nMIME: %s\nSize: %s\n%v", mimeType, humanSize(size), err))
+		}
+		if ctx.Err() != nil {
+			return previewResult{}
+		}
+		res := env.imagePreview(img, format, size)
+		if format == "jpeg" {
+			if exif := exifPreview(env.vfs, filePath); exif != "" {
+				res.content += "\n\n" + exif
+			}
+		}
+		return res
+	}
+	format := detectFormat(buf)
+	if !strings.HasPrefix(mimeType, "text/") && !format.isUTF16() {
+		header := fmt.Sprintf("MIME: %s\nSize: %s\n\n", mimeType, humanSize(size))
+		if meta, ok := metadataPreview(env.vfs, filePath, buf); ok {
+			return text(meta + "\n" + header)
+		}
+		head := make([]byte, previewLines*16)
+		copy(head, buf)
+		k, _ := io.ReadFull(r, head[n:])
+		return text("Binary file\n" + header +
+		hexDump(head[:n+k], 0, hexBytesPerRow(env.width)))
+	}
+	// Only the head is needed for preview; large files are paged with F3.
+	rest, err := io.ReadAll(io.LimitReader(r, previewMaxBytes-int64(n)))
+	if err != nil {
+		return text("Error reading file")
+	}
+	byteContent := append(buf, rest...)
+	format = detectFormat(byteContent)
+	content, _ := decodeText(byteContent, format)
+	header := ""
+	if format != defaultTextFormat {
+		header = warnStyle.Render(format.String()) + "\n"
+	}
+	if out, ok := structuredPreview(selected.title, content, size > previewMaxBytes); ok {
+		return text(header + out)
+	}
+	lines := strings.Split(content, "\n")
+	if len(lines) > previewLines {
+		lines = lines[:previewLines]
+		content = strings.Join(lines, "\n") + "\n\n… (truncated)"
+	}
+
+	// Syntax highlight
+	lexer := lexers.Match(selected.title)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return text(header + content)
+	}
+	var sb strings.Builder
+	if err = chromaFormatter.Format(&sb, chromaStyle, iterator); err != nil {
+		return text(header + content)
+	}
+	return text(header + sb.String())
+}
@@ -0,0 +1,666 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Search and replace ───────────────────────────────────────────────────────
+
+// replaceEdit is one occurrence: bytes [start,end) of line become repl.
+type replaceEdit struct {
+	line       int
+	start, end int
+	repl       string
+	on         bool
+}
+
+// replaceFile is a file with occurrences, as read when the preview was made.
+type replaceFile struct {
+	rel    string
+	data   []byte
+	format textFormat
+	lines  []string
+	edits  []replaceEdit
+}
+
+// replaceBackup is a file's content before a replace batch, kept for undo.
+type replaceBackup struct {
+	path    string
+	mode    fs.FileMode
+	before  []byte
+	written []byte
+}
+
+// replacePlan is the preview of a replace batch.
+type replacePlan struct {
+	vfs     vfsHandler
+	index   *indexer
+	root    string
+	opts    grepOptions
+	repl    string // expansion template, $1 style
+	text    string // the replacement as typed
+	files   []replaceFile
+	cursor  int // index into flat
+	offset  int
+	width   int
+	height  int
+	flat    [][2]int // (file, edit) of every occurrence in display order
+	rows    []string // laid out once; the on/off mark is added when drawn
+	at      []int    // first row of every occurrence
+	occ     []int    // occurrence whose mark row this is, or -1
+	limited bool
+}
+
+type replacePlanMsg struct {
+	gen  int
+	plan *replacePlan
+	err  error
+}
+
+// replaceDoneMsg reports an applied (or rolled back) batch.
+type replaceDoneMsg struct {
+	vfs     vfsHandler
+	backups []replaceBackup
+	summary string
+	err     error
+}
+
+// parseReplaceArgs reads the grep flags followed by either PATTERN
+// REPLACEMENT or a sed-style /PATTERN/REPLACEMENT/, which may hold spaces.
+func parseReplaceArgs(args []string) (grepOptions, string, error) {
+	usage := fmt.Errorf("usage: replace [-F] [-i] [-g GLOB] PATTERN REPLACEMENT  (or /PATTERN/REPLACEMENT/), replace undo")
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") && len(args[i]) > 1 {
+		if args[i] == "-g" || args[i] == "-C" {
+			i++
+		}
+		i++
+	}
+	if i >= len(args) {
+		return grepOptions{}, "", usage
+	}
+	var pattern, repl string
+	rest := strings.Join(args[i:], " ")
+	d := rest[:1]
+	if parts := strings.Split(rest, d); len(parts) == 4 && parts[0] == "" && parts[1] != "" && parts[3] == "" && strings.ContainsAny(d, "/|#,:;!@%") {
+		pattern, repl = parts[1], parts[2]
+	} else if len(args[i:]) == 2 {
+		pattern, repl = args[i], args[i+1]
+	} else {
+		return grepOptions{}, "", usage
+	}
+	opts, err := parseGrepArgs(append(append([]string{}, args[:i]...), "--", pattern))
+	return opts, repl, err
+}
+
+// replaceTemplate turns repl into a template for regexp.Expand; with -F the
+// replacement is literal too, so $ loses its meaning.
+func replaceTemplate(opts grepOptions, repl string) string {
+	if opts.literal {
+		return strings.ReplaceAll(repl, "$", "$$")
+	}
+	return repl
+}
+
+// startReplace scans the selected files, or the active panel's tree when
+// nothing is selected, and opens the preview.
+func (m *Model) startReplace(args []string) tea.Cmd {
+	if len(args) == 1 && args[0] == "undo" {
+		return m.undoReplace()
+	}
+	opts, repl, err := parseReplaceArgs(args)
+	if err != nil {
+		m.statusMsg = errorStyle.Render("replace: " + err.Error())
+		return nil
+	}
+	re, err := opts.compile()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("replace: " + err.Error())
+		return nil
+	}
+	text := repl
+	repl = replaceTemplate(opts, repl)
+	p := &m.panels[m.activePanel]
+	var roots []string
+	for f, sel := range p.selectedFiles {
+		if sel {
+			roots = append(roots, f)
+		}
+	}
+	sort.Strings(roots)
+	w, h := m.editorSize()
+	plan := &replacePlan{vfs: p.vfs, index: m.indexer, root: p.currentDir, opts: opts, repl: repl, text: text, width: w, height: h}
+	m.replaceGen++
+	gen := m.replaceGen
+	scope := "the directory tree"
+	if len(roots) > 0 {
+		scope = fmt.Sprintf("%d selected entries", len(roots))
+	}
+	m.statusMsg = warnStyle.Render("Searching " + scope + "…")
+	return func() tea.Msg {
+		err := plan.scan(re, roots)
+		return replacePlanMsg{gen: gen, plan: plan, err: err}
+	}
+}
+
+// scan collects the occurrences below the roots (files or directories,
+// absolute) or below plan.root when there are none.
+func (plan *replacePlan) scan(re *regexp.Regexp, roots []string) error {
+	var rels []string
+	walk := func(dir string) {
+		entries := make(chan []fuzzyEntry, 4)
+		limited := new(atomic.Bool)
+		go plan.index.walkTree(context.Background(), plan.vfs, dir, re, entries, limited)
+		for batch := range entries {
+			for _, e := range batch {
+				rel, _ := filepath.Rel(plan.root, filepath.Join(dir, e.rel))
+				if !e.isDir && plan.opts.wants(rel) {
+					rels = append(rels, rel)
+				}
+			}
+		}
+		plan.limited = plan.limited || limited.Load()
+	}
+	if len(roots) == 0 {
+		walk(plan.root)
+	}
+	for _, r := range roots {
+		info, err := plan.vfs.Stat(r)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			walk(r)
+		} else {
+			rel, _ := filepath.Rel(plan.root, r)
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	files := make([]*replaceFile, len(rels))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, grepWorkers)
+	for i, rel := range rels {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, rel string) {
+			defer func() { <-sem; wg.Done() }()
+			files[i] = plan.scanFile(re, rel)
+		}(i, rel)
+	}
+	wg.Wait()
+	total := 0
+	for _, f := range files {
+		if f == nil || total >= grepMaxMatches {
+			continue
+		}
+		total += len(f.edits)
+		plan.files = append(plan.files, *f)
+	}
+	plan.limited = plan.limited || total >= grepMaxMatches
+	for fi, f := range plan.files {
+		for ei := range f.edits {
+			plan.flat = append(plan.flat, [2]int{fi, ei})
+		}
+	}
+	plan.layout()
+	return nil
+}
+
+func (plan *replacePlan) scanFile(re *regexp.Regexp, rel string) *replaceFile {
+	f, err := plan.vfs.Open(filepath.Join(plan.root, rel))
+	if err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxFileSizeForEdit+1))
+	f.Close()
+	if err != nil || len(data) > maxFileSizeForEdit {
+		return nil
+	}
+	format := detectFormat(data)
+	if !format.isUTF16() && looksBinary(data) {
+		return nil
+	}
+	// Lines keep their own "\r", so a file with mixed endings only
+	// changes on the lines that are edited.
+	text, err := decodeBytes(data, format)
+	if err != nil {
+		return nil
+	}
+	rf := &replaceFile{rel: rel, data: data, format: format, lines: strings.Split(text, "\n")}
+	for i, line := range rf.lines {
+		body := strings.TrimSuffix(line, "\r")
+		for _, sm := range re.FindAllStringSubmatchIndex(body, -1) {
+			if sm[0] == sm[1] {
+				continue // empty matches would only insert
+			}
+			repl := string(re.ExpandString(nil, plan.repl, body, sm))
+			rf.edits = append(rf.edits, replaceEdit{line: i, start: sm[0], end: sm[1], repl: repl, on: true})
+		}
+	}
+	if len(rf.edits) == 0 {
+		return nil
+	}
+	return rf
+}
+
+// applyLine returns line with the enabled edits on it (only applies to the
+// given edit when only >= 0).
+func (f *replaceFile) applyLine(n, only int) string {
+	line := f.lines[n]
+	var sb strings.Builder
+	prev := 0
+	for i, e := range f.edits {
+		if e.line != n || !e.on && only < 0 || only >= 0 && i != only {
+			continue
+		}
+		sb.WriteString(line[prev:e.start])
+		sb.WriteString(e.repl)
+		prev = e.end
+	}
+	sb.WriteString(line[prev:])
+	return sb.String()
+}
+
+// content returns the file with every enabled edit applied, re-encoded
+// with the line endings it had.
+func (f *replaceFile) content() ([]byte, int, error) {
+	lines := append([]string(nil), f.lines...)
+	n := 0
+	done := map[int]bool{}
+	for _, e := range f.edits {
+		if e.on {
+			n++
+			if !done[e.line] {
+				lines[e.line] = f.applyLine(e.line, -1)
+				done[e.line] = true
+			}
+		}
+	}
+	tf := f.format
+	tf.crlf = false // the lines still carry their "\r"
+	out, err := encodeText(strings.Join(lines, "\n"), tf)
+	return out, n, err
+}
+
+// ─── Apply and undo ───────────────────────────────────────────────────────────
+
+// replaceInPlace swaps tmp over dst. Backends whose rename refuses to
+// overwrite (plain SFTP) get the old file removed first.
+func replaceInPlace(vfs vfsHandler, tmp, dst string) error {
+	if err := vfs.Rename(tmp, dst); err == nil {
+		return nil
+	}
+	if err := vfs.Remove(dst); err != nil {
+		return err
+	}
+	return vfs.Rename(tmp, dst)
+}
+
+// resolveLinkVFS follows symlinks so that a file is replaced where it lives
+// instead of the link being turned into a copy.
+func resolveLinkVFS(vfs vfsHandler, path string) (string, error) {
+	switch v := vfs.(type) {
+		case localVFS:
+			return filepath.EvalSymlinks(path)
+		case *sftpVFS:
+			return v.client.RealPath(path)
+	}
+	return path, nil
+}
+
+// writeReplacement writes data to a temp sibling of path carrying mode, ready
+// for replaceInPlace.
+func writeReplacement(vfs vfsHandler, path string, mode fs.FileMode, data []byte) (string, error) {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".ngt-replace")
+	err := writeVFSFile(vfs, tmp, data)
+	if err == nil {
+		err = vfs.Chmod(tmp, mode)
+	}
+	if err != nil {
+		vfs.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+func writeVFSFile(vfs vfsHandler, path string, data []byte) error {
+	w, err := vfs.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// applyReplace writes the batch all-or-nothing: every file is checked for
+// changes since the preview and written to a temp sibling first; only then
+// are the temps renamed over the originals, restoring the ones already
+// swapped if a rename fails.
+func applyReplace(plan *replacePlan) tea.Cmd {
+	return func() tea.Msg {
+		type pending struct {
+			path, tmp string
+			backup    replaceBackup
+		}
+		var todo []pending
+		cleanup := func() {
+			for _, p := range todo {
+				plan.vfs.Remove(p.tmp)
+			}
+		}
+		edits := 0
+		for i := range plan.files {
+			f := &plan.files[i]
+			out, n, err := f.content()
+			if err != nil {
+				cleanup()
+				return replaceDoneMsg{err: fmt.Errorf("%s: %w", f.rel, err)}
+			}
+			if n == 0 {
+				continue
+			}
+			path, err := resolveLinkVFS(plan.vfs, filepath.Join(plan.root, f.rel))
+			var info fs.FileInfo
+			if err == nil {
+				info, err = plan.vfs.Stat(path)
+			}
+			var cur []byte
+			if err == nil {
+				cur, err = readVFSFile(plan.vfs, path)
+			}
+			if err != nil || !bytes.Equal(cur, f.data) {
+				cleanup()
+				return replaceDoneMsg{err: fmt.Errorf("%s changed since the preview, nothing was replaced", f.rel)}
+			}
+			mode := info.Mode().Perm()
+			tmp, err := writeReplacement(plan.vfs, path, mode, out)
+			if err != nil {
+				cleanup()
+				return replaceDoneMsg{err: fmt.Errorf("%s: %w", f.rel, err)}
+			}
+			todo = append(todo, pending{path, tmp, replaceBackup{path: path, mode: mode, before: f.data, written: out}})
+			edits += n
+		}
+		var backups []replaceBackup
+		for i, p := range todo {
+			if err := replaceInPlace(plan.vfs, p.tmp, p.path); err != nil {
+				// The fallback of replaceInPlace may already have removed
+				// p.path, so it is written back along with the earlier files.
+				var lost []string
+				for _, b := range append(backups, p.backup) {
+					werr := writeVFSFile(plan.vfs, b.path, b.before)
+					if werr == nil {
+						werr = plan.vfs.Chmod(b.path, b.mode)
+					}
+					if werr != nil {
+						lost = append(lost, filepath.Base(b.path))
+					}
+				}
+				for _, q := range todo[i+1:] {
+					plan.vfs.Remove(q.tmp)
+				}
+				if len(lost) > 0 {
+					// p.tmp stays: it may be the only copy left of p.path.
+					return replaceDoneMsg{err: fmt.Errorf("%s: %w; could not restore %s (new contents of %s kept in %s)",
+						filepath.Base(p.path), err, strings.Join(lost, ", "), filepath.Base(p.path), p.tmp)}
+				}
+				plan.vfs.Remove(p.tmp)
+				return replaceDoneMsg{err: fmt.Errorf("%s: %w (all files restored)", filepath.Base(p.path), err)}
+			}
+			backups = append(backups, p.backup)
+		}
+		return replaceDoneMsg{
+			vfs:     plan.vfs,
+			backups: backups,
+			summary: fmt.Sprintf("Replaced %d occurrences in %d files – `replace undo` rolls back", edits, len(backups)),
+		}
+	}
+}
+
+func readVFSFile(vfs vfsHandler, path string) ([]byte, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// undoReplace restores the files of the last batch, skipping any that were
+// edited again since.
+func (m *Model) undoReplace() tea.Cmd {
+	backups, vfs := m.replaceUndo, m.replaceUndoVFS
+	if len(backups) == 0 {
+		m.statusMsg = warnStyle.Render("replace: nothing to undo")
+		return nil
+	}
+	m.replaceUndo, m.replaceUndoVFS = nil, nil
+	m.statusMsg = warnStyle.Render("Rolling back…")
+	return func() tea.Msg {
+		restored := 0
+		var skipped []string
+		for _, b := range backups {
+			cur, err := readVFSFile(vfs, b.path)
+			if err != nil || !bytes.Equal(cur, b.written) {
+				skipped = append(skipped, filepath.Base(b.path))
+				continue
+			}
+			tmp, err := writeReplacement(vfs, b.path, b.mode, b.before)
+			if err != nil {
+				skipped = append(skipped, filepath.Base(b.path))
+				continue
+			}
+			if err := replaceInPlace(vfs, tmp, b.path); err != nil {
+				skipped = append(skipped, filepath.Base(b.path))
+				continue
+			}
+			restored++
+		}
+		msg := replaceDoneMsg{summary: fmt.Sprintf("Rolled back %d files", restored)}
+		if len(skipped) > 0 {
+			msg.err = fmt.Errorf("rolled back %d files; changed since or unwritable: %s", restored, strings.Join(skipped, ", "))
+		}
+		return msg
+	}
+}
+
+func (m *Model) handleReplaceMsg(msg tea.Msg) {
+	switch msg := msg.(type) {
+		case replacePlanMsg:
+			if msg.gen != m.replaceGen || m.mode != explorerMode {
+				return
+			}
+			if msg.err != nil {
+				m.statusMsg = errorStyle.Render("replace: " + msg.err.Error())
+				return
+			}
+			if len(msg.plan.flat) == 0 {
+				m.statusMsg = warnStyle.Render("replace: no matches")
+				return
+			}
+			m.replace = msg.plan
+			m.statusMsg = ""
+			m.commandInput.Blur()
+			m.mode = replaceMode
+		case replaceDoneMsg:
+			if msg.err != nil {
+				m.statusMsg = errorStyle.Render("replace: " + msg.err.Error())
+			} else {
+				m.statusMsg = successStyle.Render(msg.summary)
+			}
+			if msg.backups != nil {
+				m.replaceUndo, m.replaceUndoVFS = msg.backups, msg.vfs
+			}
+			m.refreshPanel(0)
+			m.refreshPanel(1)
+	}
+}
+
+// ─── Preview ──────────────────────────────────────────────────────────────────
+
+func (m *Model) updateReplace(msg tea.KeyMsg) tea.Cmd {
+	plan := m.replace
+	switch {
+		case key.Matches(msg, m.keys.cancel), msg.String() == "q":
+			m.replace = nil
+			m.mode = explorerMode
+			m.commandInput.Focus()
+			m.statusMsg = warnStyle.Render("Replace cancelled")
+			return nil
+		case key.Matches(msg, m.keys.execute), msg.String() == "y":
+			on := plan.enabled()
+			if on == 0 {
+				m.statusMsg = warnStyle.Render("Every occurrence is switched off")
+				return nil
+			}
+			m.askConfirm(fmt.Sprintf("Replace %d occurrences? (y/n)", on), func(m *Model) tea.Cmd {
+				m.replace = nil
+				m.mode = explorerMode
+				m.commandInput.Focus()
+				m.statusMsg = warnStyle.Render("Replacing…")
+				return applyReplace(plan)
+			})
+			return nil
+		case key.Matches(msg, m.keys.selectIt):
+			fe := plan.flat[plan.cursor]
+			e := &plan.files[fe[0]].edits[fe[1]]
+			e.on = !e.on
+			plan.cursor++
+		case key.Matches(msg, m.keys.down):
+			plan.cursor++
+		case key.Matches(msg, m.keys.up):
+			plan.cursor--
+	}
+	switch msg.String() {
+		case "a":
+			// Toggle the whole file under the cursor.
+			f := &plan.files[plan.flat[plan.cursor][0]]
+			on := !f.edits[0].on
+			for i := range f.edits {
+				f.edits[i].on = on
+			}
+		case "A":
+			on := plan.enabled() == 0
+			for fi := range plan.files {
+				for i := range plan.files[fi].edits {
+					plan.files[fi].edits[i].on = on
+				}
+			}
+		case "pgdown":
+			plan.cursor += max(plan.height/3, 1)
+		case "pgup":
+			plan.cursor -= max(plan.height/3, 1)
+		case "g", "home":
+			plan.cursor = 0
+		case "G", "end":
+			plan.cursor = len(plan.flat) - 1
+	}
+	plan.cursor = max(min(plan.cursor, len(plan.flat)-1), 0)
+	plan.scroll()
+	return nil
+}
+
+func (plan *replacePlan) enabled() int {
+	n := 0
+	for _, f := range plan.files {
+		for _, e := range f.edits {
+			if e.on {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// layout renders a header per file and a before/after pair per occurrence,
+// once, so that moving and toggling don't re-render thousands of rows.
+func (plan *replacePlan) layout() {
+	expand := func(s string) string { return strings.ReplaceAll(s, "\t", "    ") }
+	for k, fe := range plan.flat {
+		f := &plan.files[fe[0]]
+		e := f.edits[fe[1]]
+		if k == 0 || plan.flat[k-1][0] != fe[0] {
+			plan.rows = append(plan.rows, dirStyle.Render(f.rel))
+			plan.occ = append(plan.occ, -1)
+		}
+		plan.at = append(plan.at, len(plan.rows))
+		line := strings.TrimSuffix(f.lines[e.line], "\r")
+		before := line[:e.start] + gitDeletedStyle.Render(line[e.start:e.end]) + line[e.end:]
+		after := line[:e.start] + gitAddedStyle.Render(e.repl) + line[e.end:]
+		plan.rows = append(plan.rows,
+			gitDeletedStyle.Render(fmt.Sprintf(" %6d - ", e.line+1))+expand(before),
+			"   "+gitAddedStyle.Render(fmt.Sprintf(" %6d + ", e.line+1))+expand(after))
+		plan.occ = append(plan.occ, k, -1)
+	}
+}
+
+// mark shows whether occurrence k is switched on.
+func (plan *replacePlan) mark(k int) string {
+	fe := plan.flat[k]
+	if plan.files[fe[0]].edits[fe[1]].on {
+		return gitAddedStyle.Render("[x]")
+	}
+	return hexZeroStyle.Render("[ ]")
+}
+
+func (plan *replacePlan) scroll() {
+	cur := plan.at[plan.cursor]
+	if cur-1 < plan.offset {
+		plan.offset = cur - 1
+	}
+	if cur+1 >= plan.offset+plan.height {
+		plan.offset = cur + 2 - plan.height
+	}
+	plan.offset = max(min(plan.offset, len(plan.rows)-plan.height), 0)
+}
+
+func (plan *replacePlan) view() string {
+	cur := plan.at[plan.cursor]
+	var sb strings.Builder
+	end := min(plan.offset+plan.height, len(plan.rows))
+	for i := plan.offset; i < end; i++ {
+		if i > plan.offset {
+			sb.WriteByte('\n')
+		}
+		prefix := "  "
+		if i == cur {
+			prefix = dirStyle.Render(selectedMarker + " ")
+		}
+		row := plan.rows[i]
+		if k := plan.occ[i]; k >= 0 {
+			row = plan.mark(k) + row
+		}
+		sb.WriteString(fitCell(prefix+row, plan.width))
+	}
+	return sb.String()
+}
+
+func (plan *replacePlan) title() string {
+	return fmt.Sprintf("%q → %q in %s%s", plan.opts.pattern, plan.text, plan.vfs.VFSName(), plan.root)
+}
+
+func (plan *replacePlan) statusLine() string {
+	s := fmt.Sprintf("%d/%d occurrences on in %d files  │  %d/%d", plan.enabled(), len(plan.flat), len(plan.files), plan.cursor+1, len(plan.flat))
+	if plan.limited {
+		s += "  (limit reached)"
+	}
+	return s
+}
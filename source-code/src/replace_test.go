@@ -0,0 +1,79 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReplaceArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		pattern string
+		repl    string
+		literal bool
+		wantErr bool
+	}{
+		{args: "foo bar", pattern: "foo", repl: "bar"},
+		{args: "/foo/bar/", pattern: "foo", repl: "bar"},
+		{args: "/two words/three more words/", pattern: "two words", repl: "three more words"},
+		{args: "/foo//", pattern: "foo", repl: ""},
+		{args: "|a/b|c/d|", pattern: "a/b", repl: "c/d"},
+		{args: "#x#y#", pattern: "x", repl: "y"},
+		{args: "-F -i /a.b/c/", pattern: "a.b", repl: "c", literal: true},
+		{args: "-g *.go -F foo bar", pattern: "foo", repl: "bar", literal: true},
+		{args: "-C 2 foo bar", pattern: "foo", repl: "bar"},
+		{args: "-i", wantErr: true},
+		{args: "foo", wantErr: true},
+		{args: "foo bar baz", wantErr: true},
+		{args: "//bar/", wantErr: true},
+		{args: "/foo/bar", wantErr: true},
+		{args: "/a/b/c/", wantErr: true},
+		{args: "-x foo bar", wantErr: true},
+	}
+	for _, tt := range tests {
+		opts, repl, err := parseReplaceArgs(strings.Fields(tt.args))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseReplaceArgs(%q) = %q, %q, want an error", tt.args, opts.pattern, repl)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseReplaceArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if opts.pattern != tt.pattern || repl != tt.repl || opts.literal != tt.literal {
+			t.Errorf("parseReplaceArgs(%q) = %q, %q, -F %v, want %q, %q, -F %v",
+				tt.args, opts.pattern, repl, opts.literal, tt.pattern, tt.repl, tt.literal)
+		}
+	}
+}
+
+func TestReplaceTemplate(t *testing.T) {
+	tests := []struct {
+		args string
+		line string
+		want string
+	}{
+		{"price cost", "price: 5", "cost: 5"},
+		{"/(\\w+)@(\\w+)/$2 at $1/", "me@host", "host at me"},
+		{"/(?P<k>\\w+)=/${k}:/", "key=1", "key:1"},
+		{"-F /$1/$2/", "a $1 b", "a $2 b"},
+		{"-F a.b $x", "a.b axb", "$x axb"},
+		{"-F -i /A.B/${0}/", "a.b", "${0}"},
+		{"a $$", "a b", "$ b"},
+	}
+	for _, tt := range tests {
+		opts, repl, err := parseReplaceArgs(strings.Fields(tt.args))
+		if err != nil {
+			t.Fatalf("parseReplaceArgs(%q): %v", tt.args, err)
+		}
+		re, err := opts.compile()
+		if err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		if got := re.ReplaceAllString(tt.line, replaceTemplate(opts, repl)); got != tt.want {
+			t.Errorf("replace %s in %q = %q, want %q", tt.args, tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package src
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/mattn/go-runewidth"
+	"gopkg.in/yaml.v3"
+)
+
+// ─── Structured previews ──────────────────────────────────────────────────────
+
+// structuredPreview renders data/document formats specially. ok is false when
+// the file should go through the generic chroma path instead. truncated means
+// content is only the head of the file, so it cannot be validated.
+func structuredPreview(name, content string, truncated bool) (string, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+		case ".json":
+			if truncated {
+				return "", false
+			}
+			return jsonPreview(content), true
+		case ".yaml", ".yml":
+			if truncated {
+				return "", false
+			}
+			return yamlPreview(content), true
+		case ".toml":
+			if truncated {
+				return "", false
+			}
+			return tomlPreview(content), true
+		case ".csv":
+			return tablePreview(content, ',', truncated), true
+		case ".tsv", ".tab":
+			return tablePreview(content, '\t', truncated), true
+		case ".md", ".markdown":
+			return markdownPreview(content, truncated), true
+	}
+	return "", false
+}
+
+// highlight runs content through the chroma lexer for lang, capped at
+// previewLines.
+func highlight(lang, content string) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > previewLines {
+		content = strings.Join(lines[:previewLines], "\n") + "\n\n… (truncated)"
+	}
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return content
+	}
+	var sb strings.Builder
+	if err := chromaFormatter.Format(&sb, chromaStyle, iterator); err != nil {
+		return content
+	}
+	return sb.String()
+}
+
+// parseErrorPreview shows err and the offending line with a caret under col
+// (1-based; 0 means unknown), followed by the highlighted source.
+func parseErrorPreview(kind, lang, content string, err error, line, col int) string {
+	var sb strings.Builder
+	sb.WriteString(errorStyle.Render(fmt.Sprintf("✗ Invalid %s: %v", kind, err)) + "\n")
+	lines := strings.Split(content, "\n")
+	if line >= 1 && line <= len(lines) {
+		src := strings.ReplaceAll(lines[line-1], "\t", " ")
+		prefix := fmt.Sprintf("%4d │ ", line)
+		sb.WriteString(hexOffsetStyle.Render(prefix) + src + "\n")
+		if col >= 1 {
+			pad := runewidth.StringWidth(prefix) + runewidth.StringWidth(string([]rune(src)[:min(col-1, len([]rune(src)))]))
+			sb.WriteString(strings.Repeat(" ", pad) + errorStyle.Render("^") + "\n")
+		}
+	}
+	return sb.String() + "\n" + highlight(lang, content)
+}
+
+// lineCol converts a byte offset into a 1-based line and column.
+func lineCol(content string, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	head := content[:offset]
+	line := strings.Count(head, "\n") + 1
+	col := len([]rune(head[strings.LastIndex(head, "\n")+1:]))
+	return line, col
+}
+
+func jsonPreview(content string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(content), "", "  "); err != nil {
+		var offset int64
+		var syn *json.SyntaxError
+		var typ *json.UnmarshalTypeError
+		switch {
+			case errors.As(err, &syn):
+				offset = syn.Offset
+			case errors.As(err, &typ):
+				offset = typ.Offset
+		}
+		line, col := lineCol(content, offset)
+		return parseErrorPreview("JSON", "json", content, err, line, col)
+	}
+	return successStyle.Render("✓ Valid JSON") + "\n\n" + highlight("json", out.String())
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+func yamlPreview(content string) string {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	docs := 0
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			line := 0
+			if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+				fmt.Sscan(m[1], &line)
+			}
+			return parseErrorPreview("YAML", "yaml", content, err, line, 0)
+		}
+		if err := enc.Encode(&node); err != nil {
+			return parseErrorPreview("YAML", "yaml", content, err, 0, 0)
+		}
+		docs++
+	}
+	enc.Close()
+	header := "✓ Valid YAML"
+	if docs > 1 {
+		header += fmt.Sprintf(" · %d documents", docs)
+	}
+	return successStyle.Render(header) + "\n\n" + highlight("yaml", out.String())
+}
+
+func tomlPreview(content string) string {
+	var data map[string]interface{}
+	meta, err := toml.Decode(content, &data)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			line, col := lineCol(content, int64(perr.Position.Start))
+			return parseErrorPreview("TOML", "toml", content, err, line, col+1)
+		}
+		return parseErrorPreview("TOML", "toml", content, err, 0, 0)
+	}
+	var out bytes.Buffer
+	enc := toml.NewEncoder(&out)
+	enc.Indent = "  "
+	if err := enc.Encode(data); err != nil {
+		return parseErrorPreview("TOML", "toml", content, err, 0, 0)
+	}
+	header := fmt.Sprintf("✓ Valid TOML · %d keys", len(meta.Keys()))
+	return successStyle.Render(header) + "\n\n" + highlight("toml", out.String())
+}
+
+// ─── CSV / TSV ────────────────────────────────────────────────────────────────
+
+const maxColumnWidth = 24
+
+func tablePreview(content string, sep rune, truncated bool) string {
+	r := csv.NewReader(strings.NewReader(content))
+	r.Comma = sep
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	var rows [][]string
+	var parseErr error
+	for len(rows) <= previewLines {
+		rec, err := r.Read()
+		if err != nil {
+			// A cut-off last record is expected when only the head was read.
+			if err != io.EOF && !truncated {
+				parseErr = err
+			}
+			break
+		}
+		rows = append(rows, rec)
+	}
+	if len(rows) == 0 {
+		if parseErr != nil {
+			return errorStyle.Render(fmt.Sprintf("✗ %v", parseErr))
+		}
+		return "(empty table)"
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = min(max(widths[i], runewidth.StringWidth(cell)), maxColumnWidth)
+		}
+	}
+
+	var sb strings.Builder
+	kind := "CSV"
+	if sep == '\t' {
+		kind = "TSV"
+	}
+	fmt.Fprintf(&sb, "%s · %d columns\n\n", kind, cols)
+	for n, row := range rows {
+		if n == previewLines {
+			sb.WriteString("\n… (truncated)")
+			break
+		}
+		cells := make([]string, cols)
+		for i := range cells {
+			cell := ""
+			if i < len(row) {
+				cell = strings.ReplaceAll(row[i], "\n", " ")
+			}
+			cell = runewidth.FillRight(runewidth.Truncate(cell, widths[i], "…"), widths[i])
+			if n == 0 {
+				cell = tableHeadStyle.Render(cell)
+			}
+			cells[i] = cell
+		}
+		sb.WriteString(strings.Join(cells, hexZeroStyle.Render(" │ ")) + "\n")
+	}
+	if parseErr != nil {
+		sb.WriteString("\n" + errorStyle.Render(fmt.Sprintf("✗ %v", parseErr)))
+	}
+	return sb.String()
+}
+
+// ─── Markdown ─────────────────────────────────────────────────────────────────
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	mdBulletRe   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdNumberRe   = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	mdRuleRe     = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	mdCodeSpanRe = regexp.MustCompile("`([^`]+)`")
+	mdBoldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+// markdownPreview styles headings, lists, quotes, rules and fenced code
+// blocks; inline code, bold text and links are styled within lines.
+func markdownPreview(content string, truncated bool) string {
+	var sb strings.Builder
+	lines := strings.Split(content, "\n")
+	out := 0
+	inFence, fenceLang := false, ""
+	var code []string
+
+	for _, line := range lines {
+		if out >= previewLines {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			if inFence {
+				sb.WriteString(strings.TrimRight(highlight(fenceLang, strings.Join(code, "\n")), "\n") + "\n")
+				inFence, code = false, nil
+			} else {
+				inFence, fenceLang = true, strings.TrimSpace(trimmed[3:])
+				if fenceLang == "" {
+					fenceLang = "plaintext"
+				}
+			}
+			continue
+		}
+		if inFence {
+			code = append(code, line)
+			out++
+			continue
+		}
+		out++
+		switch {
+			case mdHeadingRe.MatchString(line):
+				m := mdHeadingRe.FindStringSubmatch(line)
+				text := m[2]
+				if len(m[1]) == 1 {
+					text = strings.ToUpper(text)
+				}
+				sb.WriteString(mdHeadingStyle.Render(strings.Repeat("▍", min(len(m[1]), 3))+" "+text) + "\n")
+			case mdRuleRe.MatchString(line):
+				sb.WriteString(hexZeroStyle.Render(strings.Repeat("─", 40)) + "\n")
+			case strings.HasPrefix(trimmed, ">"):
+				sb.WriteString(mdQuoteStyle.Render("│ "+mdInline(strings.TrimSpace(strings.TrimLeft(trimmed, ">")))) + "\n")
+			case mdBulletRe.MatchString(line):
+				m := mdBulletRe.FindStringSubmatch(line)
+				text := m[2]
+				switch {
+					case strings.HasPrefix(text, "[ ] "):
+						text = "☐ " + text[4:]
+					case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[X] "):
+						text = "☑ " + text[4:]
+				}
+				sb.WriteString(m[1] + mdHeadingStyle.Render("•") + " " + mdInline(text) + "\n")
+			case mdNumberRe.MatchString(line):
+				m := mdNumberRe.FindStringSubmatch(line)
+				sb.WriteString(m[1] + mdHeadingStyle.Render(m[2]+".") + " " + mdInline(m[3]) + "\n")
+			default:
+				sb.WriteString(mdInline(line) + "\n")
+		}
+	}
+	if inFence && len(code) > 0 {
+		sb.WriteString(highlight(fenceLang, strings.Join(code, "\n")))
+	}
+	if truncated || out >= previewLines {
+		sb.WriteString("\n… (truncated)")
+	}
+	return sb.String()
+}
+
+func mdInline(s string) string {
+	s = mdCodeSpanRe.ReplaceAllStringFunc(s, func(m string) string {
+		return mdCodeStyle.Render(m[1 : len(m)-1])
+	})
+	s = mdBoldRe.ReplaceAllStringFunc(s, func(m string) string {
+		return mdBoldStyle.Render(m[2 : len(m)-2])
+	})
+	return mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdLinkRe.FindStringSubmatch(m)
+		return dirStyle.Render(sub[1]) + hexOffsetStyle.Render(" <"+sub[2]+">")
+	})
+}
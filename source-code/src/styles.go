@@ -12,6 +12,7 @@ const (
 	version            = "v0.3"
 	maxFileSizeForEdit = 10 * 1024 * 1024
 	previewLines       = 40
+	previewMaxBytes    = 64 * 1024
 	selectedMarker     = "▶"
 )
 
@@ -127,6 +128,42 @@ var (
 	Padding(1).
 	Background(lipgloss.Color(colorSurface))
 
+	// Editor buffer tabs
+	bufferTabStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color(colorMuted)).
+	Background(lipgloss.Color(colorSurface)).
+	Padding(0, 1)
+
+	bufferTabActiveStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color(colorBg)).
+	Background(lipgloss.Color(colorAccent)).
+	Padding(0, 1).
+	Bold(true)
+
+	// Hex view
+	hexOffsetStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted))
+	hexZeroStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color(colorDim))
+	hexModifiedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(colorYellow)).Bold(true)
+	hexCursorStyle   = lipgloss.NewStyle().
+	Foreground(lipgloss.Color(colorBg)).
+	Background(lipgloss.Color(colorAccent))
+
+	// Pager search matches
+	pagerMatchStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color(colorBg)).
+	Background(lipgloss.Color(colorYellow))
+
+	// Structured previews
+	mdHeadingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Bold(true)
+	mdCodeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color(colorYellow))
+	mdQuoteStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Italic(true)
+	mdBoldStyle    = lipgloss.NewStyle().Bold(true)
+	tableHeadStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Bold(true).Underline(true)
+	metaKeyStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted))
+
+	// Fuzzy finder matched characters
+	fuzzyMatchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(colorYellow)).Bold(true)
+
 	// Preview pane
 	previewStyle = lipgloss.NewStyle().
 	Border(lipgloss.RoundedBorder()).
@@ -180,6 +217,8 @@ var (
 func renderFBar(w int) string {
 	segments := []struct{ key, desc string }{
 		{"F1", "Help"},
+		{"F3", "View"},
+		{"F4", "Edit"},
 		{"F5", "Copy"},
 		{"F6", "Move"},
 		{"F8", "Delete"},
@@ -195,5 +234,5 @@ func renderFBar(w int) string {
 		parts = append(parts, fBarKeyStyle.Render(s.key)+fBarDescStyle.Render(s.desc))
 	}
 	bar := lipgloss.JoinHorizontal(lipgloss.Top, parts...)
-	return fBarStyle.Width(w).Render(bar)
+	return fBarStyle.Width(w).MaxWidth(w).MaxHeight(1).Render(bar)
 }
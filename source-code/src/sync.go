@@ -0,0 +1,521 @@
+package src
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Directory sync ───────────────────────────────────────────────────────────
+
+type syncKind int
+
+const (
+	syncMkdir syncKind = iota
+	syncCopy
+	syncUpdate
+	syncDelete
+	syncConflict
+)
+
+// syncAction is one planned step; toB tells the direction (A is the active
+// panel), rel is relative to both roots.
+type syncAction struct {
+	kind   syncKind
+	rel    string
+	toB    bool
+	size   int64
+	reason string // conflicts only
+}
+
+// syncRecord is what a sync remembers about one path on one side.
+type syncRecord struct {
+	Size  int64     `json:"size"`
+	MTime time.Time `json:"mtime"`
+	IsDir bool      `json:"dir,omitempty"`
+}
+
+// syncTree maps slash-separated relative paths to their records.
+type syncTree map[string]syncRecord
+
+// syncState is the last synced view of both sides, for two-way conflicts.
+type syncState struct {
+	A syncTree `json:"a"`
+	B syncTree `json:"b"`
+}
+
+// syncPlan is the dry run shown before anything is changed.
+type syncPlan struct {
+	a, b    compareSide
+	twoWay  bool
+	del     bool
+	state   syncState // as loaded for the plan
+	actions []syncAction
+	offset  int
+	width   int
+	height  int
+}
+
+type syncPlanMsg struct {
+	gen  int
+	plan *syncPlan
+	err  error
+}
+
+// startSync scans both panels in the background and shows the plan.
+func (m *Model) startSync(twoWay, del bool) tea.Cmd {
+	m.syncGen++
+	gen := m.syncGen
+	w, h := m.editorSize()
+	plan := &syncPlan{
+		a:      compareSide{m.panels[m.activePanel].vfs, m.panels[m.activePanel].currentDir},
+		b:      compareSide{m.panels[1-m.activePanel].vfs, m.panels[1-m.activePanel].currentDir},
+		twoWay: twoWay,
+		del:    del,
+		width:  w,
+		height: h,
+	}
+	m.statusMsg = warnStyle.Render("Scanning both sides for sync…")
+	return func() tea.Msg {
+		err := plan.build()
+		return syncPlanMsg{gen: gen, plan: plan, err: err}
+	}
+}
+
+func (m *Model) handleSyncPlanMsg(msg syncPlanMsg) {
+	if msg.gen != m.syncGen || m.mode != explorerMode {
+		return
+	}
+	if msg.err != nil {
+		m.statusMsg = errorStyle.Render(fmt.Sprintf("sync: %v", msg.err))
+		return
+	}
+	m.sync = msg.plan
+	m.commandInput.Blur()
+	m.mode = syncMode
+	m.statusMsg = ""
+	if len(m.sync.actions) == 0 {
+		m.statusMsg = successStyle.Render("Already in sync")
+	}
+}
+
+// scanSyncTree lists everything below root.
+func scanSyncTree(vfs vfsHandler, root string) (syncTree, error) {
+	tree := syncTree{}
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		entries, err := vfs.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			r := path.Join(rel, e.Name())
+			tree[r] = syncRecord{Size: info.Size(), MTime: info.ModTime(), IsDir: e.IsDir()}
+			if len(tree) > dirStatsMax {
+				return fmt.Errorf("more than %d entries below %s", dirStatsMax, root)
+			}
+			if e.IsDir() {
+				if err := walk(filepath.Join(dir, e.Name()), r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return tree, walk(root, "")
+}
+
+func sameFile(a, b syncRecord) bool {
+	dt := a.MTime.Sub(b.MTime)
+	return a.Size == b.Size && dt <= compareMTimeSlack && dt >= -compareMTimeSlack
+}
+
+func (p *syncPlan) build() error {
+	ta, err := scanSyncTree(p.a.vfs, p.a.dir)
+	if err != nil {
+		return err
+	}
+	tb, err := scanSyncTree(p.b.vfs, p.b.dir)
+	if err != nil {
+		return err
+	}
+	p.state = loadSyncState(p.a, p.b)
+	state := p.state
+	rels := make([]string, 0, len(ta)+len(tb))
+	for rel := range ta {
+		rels = append(rels, rel)
+	}
+	for rel := range tb {
+		if _, ok := ta[rel]; !ok {
+			rels = append(rels, rel)
+		}
+	}
+	// Parents sort before their children, so directories are made first
+	// and a deleted or conflicting directory hides its contents.
+	sort.Strings(rels)
+	hidden := ""
+	for _, rel := range rels {
+		if hidden != "" && strings.HasPrefix(rel, hidden+"/") {
+			continue
+		}
+		var act *syncAction
+		if p.twoWay {
+			act = p.twoWayAction(rel, ta, tb, state)
+		} else {
+			a, inA := ta[rel]
+			b, inB := tb[rel]
+			act = p.oneWayAction(rel, a, inA, b, inB)
+		}
+		if act == nil {
+			continue
+		}
+		if act.kind == syncDelete || act.kind == syncConflict {
+			hidden = rel
+		}
+		p.actions = append(p.actions, *act)
+	}
+	return nil
+}
+
+// oneWayAction mirrors A onto B: files are copied when missing, of another
+// size or newer on A.
+func (p *syncPlan) oneWayAction(rel string, a syncRecord, inA bool, b syncRecord, inB bool) *syncAction {
+	switch {
+		case !inA:
+			if p.del {
+				return &syncAction{kind: syncDelete, rel: rel, toB: true, size: b.Size}
+			}
+		case !inB && a.IsDir:
+			return &syncAction{kind: syncMkdir, rel: rel, toB: true}
+		case !inB:
+			return &syncAction{kind: syncCopy, rel: rel, toB: true, size: a.Size}
+		case a.IsDir != b.IsDir:
+			return &syncAction{kind: syncConflict, rel: rel, reason: "file on one side, directory on the other"}
+		case !a.IsDir && (a.Size != b.Size || a.MTime.Sub(b.MTime) > compareMTimeSlack):
+			return &syncAction{kind: syncUpdate, rel: rel, toB: true, size: a.Size}
+	}
+	return nil
+}
+
+// twoWayAction propagates whichever side changed since the last sync and
+// flags paths changed on both.
+func (p *syncPlan) twoWayAction(rel string, ta, tb syncTree, state syncState) *syncAction {
+	a, inA := ta[rel]
+	b, inB := tb[rel]
+	changed := func(rel string, r syncRecord, last syncTree) bool {
+		old, ok := last[rel]
+		return !ok || old.IsDir != r.IsDir || (!r.IsDir && !sameFile(old, r))
+	}
+	// only lists the path on one side: new there, or deleted on the other.
+	// A directory is only deleted when nothing below it changed either,
+	// since removing it takes its whole contents along.
+	only := func(r syncRecord, cur, mine, theirs syncTree, toB bool) *syncAction {
+		if _, was := theirs[rel]; was {
+			if changed(rel, r, mine) {
+				return &syncAction{kind: syncConflict, rel: rel, reason: "changed on one side, deleted on the other"}
+			}
+			if r.IsDir {
+				for sub, sr := range cur {
+					if strings.HasPrefix(sub, rel+"/") && changed(sub, sr, mine) {
+						return &syncAction{kind: syncConflict, rel: rel, reason: "deleted on one side, " + sub + " changed on the other"}
+					}
+				}
+			}
+			if p.del {
+				return &syncAction{kind: syncDelete, rel: rel, toB: !toB, size: r.Size}
+			}
+		}
+		if r.IsDir {
+			return &syncAction{kind: syncMkdir, rel: rel, toB: toB}
+		}
+		return &syncAction{kind: syncCopy, rel: rel, toB: toB, size: r.Size}
+	}
+	switch {
+		case !inB:
+			return only(a, ta, state.A, state.B, true)
+		case !inA:
+			return only(b, tb, state.B, state.A, false)
+		case a.IsDir != b.IsDir:
+			return &syncAction{kind: syncConflict, rel: rel, reason: "file on one side, directory on the other"}
+		case a.IsDir || sameFile(a, b):
+			return nil
+	}
+	ca, cb := changed(rel, a, state.A), changed(rel, b, state.B)
+	switch {
+		case ca && !cb:
+			return &syncAction{kind: syncUpdate, rel: rel, toB: true, size: a.Size}
+		case cb && !ca:
+			return &syncAction{kind: syncUpdate, rel: rel, toB: false, size: b.Size}
+		case !ca && !cb:
+			// Both as last synced; the copies merely differ in mtime.
+			return nil
+	}
+	return &syncAction{kind: syncConflict, rel: rel, reason: "changed on both sides"}
+}
+
+// ─── Sync state ───────────────────────────────────────────────────────────────
+
+// syncStatePath names the state file of a directory pair; the pair is
+// ordered so that either panel may be the active one. swapped reports
+// that a and b are stored as B and A.
+func syncStatePath(a, b compareSide) (file string, swapped bool) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	ka, kb := a.vfs.VFSName()+a.dir, b.vfs.VFSName()+b.dir
+	if ka > kb {
+		ka, kb, swapped = kb, ka, true
+	}
+	sum := sha256.Sum256([]byte(ka + "\x00" + kb))
+	return filepath.Join(dir, appName, "sync", fmt.Sprintf("%x.json", sum[:8])), swapped
+}
+
+// loadSyncState returns the state of the last two-way sync; without one
+// every difference is treated as a conflict.
+func loadSyncState(a, b compareSide) syncState {
+	var state syncState
+	file, swapped := syncStatePath(a, b)
+	if file == "" {
+		return state
+	}
+	data, err := os.ReadFile(file)
+	if err != nil || json.Unmarshal(data, &state) != nil {
+		return syncState{}
+	}
+	if swapped {
+		state.A, state.B = state.B, state.A
+	}
+	return state
+}
+
+// saveSyncState records both trees as synced, except the paths in keep
+// (conflicts and failed actions, with everything below them): those keep
+// their records from prev, so the next two-way run still sees them changed.
+func saveSyncState(a, b compareSide, prev syncState, keep []string) error {
+	ta, err := scanSyncTree(a.vfs, a.dir)
+	if err != nil {
+		return err
+	}
+	tb, err := scanSyncTree(b.vfs, b.dir)
+	if err != nil {
+		return err
+	}
+	for _, rel := range keep {
+		carrySyncRecords(ta, prev.A, rel)
+		carrySyncRecords(tb, prev.B, rel)
+	}
+	file, swapped := syncStatePath(a, b)
+	if file == "" {
+		return fmt.Errorf("no config directory")
+	}
+	if swapped {
+		ta, tb = tb, ta
+	}
+	data, err := json.Marshal(syncState{A: ta, B: tb})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// carrySyncRecords replaces the records of rel and its descendants in cur
+// with those in prev.
+func carrySyncRecords(cur, prev syncTree, rel string) {
+	under := func(r string) bool { return r == rel || strings.HasPrefix(r, rel+"/") }
+	for r := range cur {
+		if under(r) {
+			delete(cur, r)
+		}
+	}
+	for r, rec := range prev {
+		if under(r) {
+			cur[r] = rec
+		}
+	}
+}
+
+// ─── Apply ────────────────────────────────────────────────────────────────────
+
+// applySync runs the plan like the other file operations: progress and the
+// result arrive through the model's channels. Conflicts are left alone.
+func (m *Model) applySync(plan *syncPlan) {
+	var total, done int64
+	for _, act := range plan.actions {
+		total += act.size
+	}
+	total = max(total, 1)
+	counts := map[syncKind]int{}
+	var failed, keep []string
+	for _, act := range plan.actions {
+		src, dst := plan.a, plan.b
+		if !act.toB {
+			src, dst = dst, src
+		}
+		from := filepath.Join(src.dir, filepath.FromSlash(act.rel))
+		to := filepath.Join(dst.dir, filepath.FromSlash(act.rel))
+		var err error
+		switch act.kind {
+			case syncMkdir:
+				err = dst.vfs.MkdirAll(to, 0o755)
+			case syncCopy, syncUpdate:
+				base := done
+				err = copyFileKeepMTime(src.vfs, dst.vfs, from, to, func(pct float64) {
+					m.ProgressChan <- ProgressMsg{Percent: min((float64(base)+pct*float64(act.size))/float64(total), 0.99)}
+				})
+			case syncDelete:
+				err = dst.vfs.Remove(to)
+			case syncConflict:
+				counts[act.kind]++
+				keep = append(keep, act.rel)
+				continue
+		}
+		done += act.size
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", act.rel, err))
+			keep = append(keep, act.rel)
+			continue
+		}
+		counts[act.kind]++
+	}
+	// One-way runs record state too, so a later two-way sync starts from a
+	// known baseline instead of seeing every copy as a conflict.
+	if err := saveSyncState(plan.a, plan.b, plan.state, keep); err != nil {
+		failed = append(failed, fmt.Sprintf("saving sync state: %v", err))
+	}
+	m.ProgressChan <- ProgressMsg{Percent: 1.0}
+	out := fmt.Sprintf("Synced: %d copied, %d updated, %d dirs made, %d deleted, %d conflicts skipped",
+		counts[syncCopy], counts[syncUpdate], counts[syncMkdir], counts[syncDelete], counts[syncConflict])
+	if len(failed) > 0 {
+		m.ResultChan <- CommandResult{Output: out + "\n" + strings.Join(failed, "\n"), Err: fmt.Errorf("%d sync actions failed", len(failed))}
+		return
+	}
+	m.ResultChan <- CommandResult{Output: out}
+}
+
+// ─── Plan view ────────────────────────────────────────────────────────────────
+
+func (m *Model) updateSync(msg tea.KeyMsg) tea.Cmd {
+	p := m.sync
+	switch {
+		case key.Matches(msg, m.keys.cancel), msg.String() == "q":
+			m.sync = nil
+			m.mode = explorerMode
+			m.commandInput.Focus()
+			m.statusMsg = warnStyle.Render("Sync cancelled")
+			return nil
+		case key.Matches(msg, m.keys.execute), msg.String() == "y":
+			n := 0
+			for _, act := range p.actions {
+				if act.kind != syncConflict {
+					n++
+				}
+			}
+			if n == 0 {
+				m.statusMsg = warnStyle.Render("Nothing to apply")
+				return nil
+			}
+			m.askConfirm(fmt.Sprintf("Apply %d sync actions? (y/n)", n), func(m *Model) tea.Cmd {
+				m.sync = nil
+				m.commandInput.Focus()
+				m.mode = progressMode
+				go m.applySync(p)
+				return nil
+			})
+			return nil
+		case key.Matches(msg, m.keys.down):
+			p.offset++
+		case key.Matches(msg, m.keys.up):
+			p.offset--
+	}
+	switch msg.String() {
+		case "pgdown", " ":
+			p.offset += p.height - 1
+		case "pgup", "b":
+			p.offset -= p.height - 1
+		case "g", "home":
+			p.offset = 0
+		case "G", "end":
+			p.offset = len(p.actions)
+	}
+	p.clamp()
+	return nil
+}
+
+func (p *syncPlan) clamp() {
+	p.offset = max(min(p.offset, len(p.actions)-p.height), 0)
+}
+
+func (p *syncPlan) view() string {
+	if len(p.actions) == 0 {
+		return metaKeyStyle.Render("(nothing to do)")
+	}
+	var sb strings.Builder
+	end := min(p.offset+p.height, len(p.actions))
+	for i := p.offset; i < end; i++ {
+		act := p.actions[i]
+		arrow := "→"
+		if !act.toB {
+			arrow = "←"
+		}
+		var line string
+		switch act.kind {
+			case syncMkdir:
+				line = gitAddedStyle.Render("mkdir   "+arrow) + " " + dirStyle.Render(act.rel+"/")
+			case syncCopy:
+				line = gitAddedStyle.Render("copy    "+arrow) + " " + act.rel + "  " + metaKeyStyle.Render(humanSize(act.size))
+			case syncUpdate:
+				line = gitModifiedStyle.Render("update  "+arrow) + " " + act.rel + "  " + metaKeyStyle.Render(humanSize(act.size))
+			case syncDelete:
+				line = gitDeletedStyle.Render("delete  "+arrow) + " " + act.rel
+			case syncConflict:
+				line = errorStyle.Render("conflict !") + " " + act.rel + "  " + warnStyle.Render(act.reason)
+		}
+		if i > p.offset {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(fitCell("  "+line, p.width))
+	}
+	return sb.String()
+}
+
+func (p *syncPlan) title() string {
+	mode := "→"
+	if p.twoWay {
+		mode = "⇄"
+	}
+	return fmt.Sprintf("%s%s  %s  %s%s", p.a.vfs.VFSName(), p.a.dir, mode, p.b.vfs.VFSName(), p.b.dir)
+}
+
+func (p *syncPlan) statusLine() string {
+	counts := map[syncKind]int{}
+	var bytes int64
+	for _, act := range p.actions {
+		counts[act.kind]++
+		if act.kind == syncCopy || act.kind == syncUpdate {
+			bytes += act.size
+		}
+	}
+	del := "keep extraneous"
+	if p.del {
+		del = "delete extraneous"
+	}
+	return fmt.Sprintf("dry run  │  %d copy, %d update, %d mkdir, %d delete, %d conflicts  │  %s to transfer  │  %s",
+		counts[syncCopy], counts[syncUpdate], counts[syncMkdir], counts[syncDelete], counts[syncConflict], humanSize(bytes), del)
+}
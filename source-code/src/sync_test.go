@@ -0,0 +1,147 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSyncPair makes two directories with the same files and a saved sync
+// state for them, as left behind by a completed two-way sync.
+func newSyncPair(t *testing.T, files map[string]string) (a, b compareSide) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	a = compareSide{localVFS{}, t.TempDir()}
+	b = compareSide{localVFS{}, t.TempDir()}
+	when := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for rel, data := range files {
+		for _, side := range []compareSide{a, b} {
+			writeSyncFile(t, filepath.Join(side.dir, rel), data, when)
+		}
+	}
+	if err := saveSyncState(a, b, syncState{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+func writeSyncFile(t *testing.T, file, data string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func buildSyncPlan(t *testing.T, a, b compareSide) []syncAction {
+	t.Helper()
+	plan := &syncPlan{a: a, b: b, twoWay: true, del: true}
+	if err := plan.build(); err != nil {
+		t.Fatal(err)
+	}
+	return plan.actions
+}
+
+func TestSyncDeletedDirWithChangedContents(t *testing.T) {
+	a, b := newSyncPair(t, map[string]string{"x/f": "f", "x/g": "g", "y": "y"})
+	if err := os.RemoveAll(filepath.Join(a.dir, "x")); err != nil {
+		t.Fatal(err)
+	}
+	writeSyncFile(t, filepath.Join(b.dir, "x", "g"), "edited", time.Now())
+
+	acts := buildSyncPlan(t, a, b)
+	if len(acts) != 1 || acts[0].kind != syncConflict || acts[0].rel != "x" {
+		t.Fatalf("want a single conflict on x, got %+v", acts)
+	}
+}
+
+func TestSyncDeletedDirUnchanged(t *testing.T) {
+	a, b := newSyncPair(t, map[string]string{"x/f": "f", "x/g": "g"})
+	if err := os.RemoveAll(filepath.Join(a.dir, "x")); err != nil {
+		t.Fatal(err)
+	}
+
+	acts := buildSyncPlan(t, a, b)
+	if len(acts) != 1 || acts[0].kind != syncDelete || acts[0].rel != "x" || !acts[0].toB {
+		t.Fatalf("want x deleted on B, got %+v", acts)
+	}
+}
+
+func TestSyncDeletedDirWithNewFile(t *testing.T) {
+	a, b := newSyncPair(t, map[string]string{"x/f": "f"})
+	if err := os.RemoveAll(filepath.Join(b.dir, "x")); err != nil {
+		t.Fatal(err)
+	}
+	writeSyncFile(t, filepath.Join(a.dir, "x", "new"), "new", time.Now())
+
+	acts := buildSyncPlan(t, a, b)
+	if len(acts) != 1 || acts[0].kind != syncConflict || acts[0].rel != "x" {
+		t.Fatalf("want a single conflict on x, got %+v", acts)
+	}
+}
+
+func TestSyncSettlesAfterCopy(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	a := compareSide{localVFS{}, t.TempDir()}
+	b := compareSide{localVFS{}, t.TempDir()}
+	writeSyncFile(t, filepath.Join(a.dir, "f"), "data", time.Now().Add(-time.Hour))
+	if err := saveSyncState(a, b, syncState{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	acts := buildSyncPlan(t, a, b)
+	if len(acts) != 1 || acts[0].kind != syncCopy || !acts[0].toB {
+		t.Fatalf("want f copied to B, got %+v", acts)
+	}
+	from, to := filepath.Join(a.dir, "f"), filepath.Join(b.dir, "f")
+	if err := copyFileKeepMTime(a.vfs, b.vfs, from, to, func(float64) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveSyncState(a, b, syncState{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if acts := buildSyncPlan(t, a, b); len(acts) != 0 {
+		t.Fatalf("want nothing to do after the copy, got %+v", acts)
+	}
+}
+
+func TestSyncUnchangedSidesWithDifferentMTimes(t *testing.T) {
+	a, b := newSyncPair(t, map[string]string{"f": "data"})
+	// A copy made without keeping the mtime, then recorded as synced.
+	writeSyncFile(t, filepath.Join(b.dir, "f"), "data", time.Now())
+	if err := saveSyncState(a, b, syncState{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if acts := buildSyncPlan(t, a, b); len(acts) != 0 {
+		t.Fatalf("want nothing to do, got %+v", acts)
+	}
+}
+
+func TestSyncConflictSurvivesApply(t *testing.T) {
+	a, b := newSyncPair(t, map[string]string{"f": "f", "g": "g"})
+	writeSyncFile(t, filepath.Join(a.dir, "f"), "edited on A", time.Now())
+	writeSyncFile(t, filepath.Join(b.dir, "f"), "edited on B", time.Now().Add(time.Minute))
+	writeSyncFile(t, filepath.Join(a.dir, "g"), "edited", time.Now())
+
+	plan := &syncPlan{a: a, b: b, twoWay: true, del: true}
+	if err := plan.build(); err != nil {
+		t.Fatal(err)
+	}
+	m := &Model{ProgressChan: make(chan ProgressMsg, 16), ResultChan: make(chan CommandResult, 1)}
+	m.applySync(plan)
+	if res := <-m.ResultChan; res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	acts := buildSyncPlan(t, a, b)
+	if len(acts) != 1 || acts[0].kind != syncConflict || acts[0].rel != "f" {
+		t.Fatalf("want the conflict on f to remain, got %+v", acts)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package src
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// termCellSize returns the pixel size of one terminal cell, falling back to
+// 8×16 when the terminal does not report its pixel dimensions.
+func termCellSize() (int, int) {
+	var ws struct {
+		rows, cols, xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 || ws.cols == 0 || ws.rows == 0 || ws.xpixel == 0 || ws.ypixel == 0 {
+		return 8, 16
+	}
+	return int(ws.xpixel / ws.cols), int(ws.ypixel / ws.rows)
+}
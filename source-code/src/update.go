@@ -12,6 +12,13 @@ import (
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	nm := next.(Model)
+	cmd = tea.Batch(cmd, nm.watchCmds(), nm.previewCmds(), nm.flushGraphics())
+	return nm, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -28,6 +35,48 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.refreshPanel(1 - m.activePanel)
 			m.mode = explorerMode
 
+		case openerDoneMsg:
+			m.finishOpener(msg)
+			return m, nil
+
+		case previewMsg:
+			return m, m.handlePreviewMsg(msg)
+
+		case pagerIndexMsg, pagerSearchMsg, pagerTickMsg:
+			return m, m.handlePagerMsg(msg)
+
+		case duScanMsg, duTickMsg:
+			return m, m.handleDiskUsageMsg(msg)
+
+		case duDeleteMsg:
+			m.handleDiskUsageDelete(msg)
+			return m, nil
+
+		case compareMsg:
+			m.handleCompareMsg(msg)
+			return m, nil
+
+		case findMsg:
+			m.handleFindMsg(msg)
+			return m, nil
+
+		case dirChangedMsg:
+			return m, m.handleDirChanged(msg)
+
+		case fuzzyBatchMsg:
+			return m, m.handleFuzzyBatch(msg)
+
+		case grepBatchMsg:
+			return m, m.handleGrepBatch(msg)
+
+		case replacePlanMsg, replaceDoneMsg:
+			m.handleReplaceMsg(msg)
+			return m, nil
+
+		case syncPlanMsg:
+			m.handleSyncPlanMsg(msg)
+			return m, nil
+
 		case ProgressMsg:
 			cmd = m.progress.SetPercent(msg.Percent)
 			cmds = append(cmds, cmd)
@@ -48,12 +97,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.mode == confirmMode {
 				switch msg.String() {
 					case "y", "Y":
+						m.mode = m.confirmPrev
 						if m.confirmAction != nil {
-							m.confirmAction()
+							return m, m.confirmAction(&m)
 						}
-						m.mode = explorerMode
 					case "n", "N", "esc":
-						m.mode = explorerMode
+						m.mode = m.confirmPrev
 						m.statusMsg = warnStyle.Render("Cancelled")
 				}
 				return m, nil
@@ -78,45 +127,158 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Editor mode
 			if m.mode == editorMode {
-				if key.Matches(msg, m.keys.save) {
-					err := os.WriteFile(m.editorFile, []byte(m.editor.Value()), 0644)
-					if err != nil {
-						m.statusMsg = errorStyle.Render(fmt.Sprintf("Save error: %v", err))
-					} else {
-						m.statusMsg = successStyle.Render("Saved: " + m.editorFile)
-					}
+				b := m.currentBuffer()
+				if b == nil {
 					m.mode = explorerMode
-					m.commandInput.Focus()
 					return m, nil
 				}
-				if key.Matches(msg, m.keys.cancel) {
-					m.mode = explorerMode
-					m.commandInput.Focus()
-					return m, nil
+				switch {
+					case key.Matches(msg, m.keys.save):
+						if err := m.saveBuffer(m.activeBuffer); err != nil {
+							m.statusMsg = errorStyle.Render(fmt.Sprintf("Save error: %v", err))
+						} else {
+							m.statusMsg = successStyle.Render("Saved: " + b.file)
+						}
+						return m, nil
+					case key.Matches(msg, m.keys.cancel):
+						m.mode = explorerMode
+						m.commandInput.Focus()
+						return m, nil
+					case key.Matches(msg, m.keys.bufferNext):
+						m.cycleBuffer(1)
+						return m, nil
+					case key.Matches(msg, m.keys.bufferPrev):
+						m.cycleBuffer(-1)
+						return m, nil
+					case key.Matches(msg, m.keys.bufferList):
+						m.openBufferList()
+						return m, nil
+					case key.Matches(msg, m.keys.bufferClose):
+						m.promptCloseBuffer()
+						return m, nil
+					case key.Matches(msg, m.keys.cycleEncoding):
+						b.format = nextEncoding(b.format)
+						b.updateDirty()
+						m.statusMsg = warnStyle.Render("Will save as " + b.format.String())
+						return m, nil
+					case key.Matches(msg, m.keys.toggleEOL):
+						b.format.crlf = !b.format.crlf
+						b.updateDirty()
+						m.statusMsg = warnStyle.Render("Will save as " + b.format.String())
+						return m, nil
 				}
-				m.editor, cmd = m.editor.Update(msg)
+				b.editor, cmd = b.editor.Update(msg)
+				b.updateDirty()
 				cmds = append(cmds, cmd)
 				return m, tea.Batch(cmds...)
 			}
 
+			// Hex editor mode
+			if m.mode == hexMode {
+				return m, m.updateHexEditor(msg)
+			}
+
+			// Pager mode
+			if m.mode == pagerMode {
+				return m, m.updatePager(msg)
+			}
+
+			// Disk usage mode
+			if m.mode == duMode {
+				return m, m.updateDiskUsage(msg)
+			}
+
+			// Diff mode
+			if m.mode == diffMode {
+				return m, m.updateDiff(msg)
+			}
+
+			// Content search results
+			if m.mode == grepMode {
+				return m, m.updateGrep(msg)
+			}
+
+			// Replace preview
+			if m.mode == replaceMode {
+				return m, m.updateReplace(msg)
+			}
+
+			// Sync plan mode
+			if m.mode == syncMode {
+				return m, m.updateSync(msg)
+			}
+
+			// Buffer list mode
+			if m.mode == bufferListMode {
+				switch {
+					case key.Matches(msg, m.keys.cancel):
+						if len(m.buffers) > 0 {
+							m.mode = editorMode
+						} else {
+							m.mode = explorerMode
+						}
+					case key.Matches(msg, m.keys.up):
+						if m.bufferCursor > 0 {
+							m.bufferCursor--
+						}
+					case key.Matches(msg, m.keys.down):
+						if m.bufferCursor < len(m.buffers)-1 {
+							m.bufferCursor++
+						}
+					case key.Matches(msg, m.keys.execute):
+						m.switchBuffer(m.bufferCursor)
+					case key.Matches(msg, m.keys.bufferClose):
+						m.activeBuffer = m.bufferCursor
+						m.promptCloseBuffer()
+						if m.mode != confirmMode {
+							if len(m.buffers) == 0 {
+								m.mode = explorerMode
+							} else {
+								m.mode = bufferListMode
+								if m.bufferCursor >= len(m.buffers) {
+									m.bufferCursor = len(m.buffers) - 1
+								}
+							}
+						}
+				}
+				return m, nil
+			}
+
 			// Fuzzy mode
 			if m.mode == fuzzyMode {
 				if key.Matches(msg, m.keys.execute) {
-					if len(m.fuzzyResults) > m.panels[m.activePanel].fileList.Index() {
-						selected := m.fuzzyResults[m.panels[m.activePanel].fileList.Index()]
-						m.executeCommand("cd " + selected)
-					}
-					m.mode = explorerMode
+					m.fuzzyOpen(false)
+					return m, nil
+				}
+				if key.Matches(msg, m.keys.fuzzyEdit) {
+					return m, m.fuzzyOpen(true)
+				}
+				if key.Matches(msg, m.keys.selectIt) {
+					m.fuzzyToggleSelect()
 					return m, nil
 				}
 				if key.Matches(msg, m.keys.cancel) {
+					m.fuzzy.stop()
 					m.mode = explorerMode
-					m.refreshPanel(m.activePanel)
 					return m, nil
 				}
+				switch msg.String() {
+					case "up", "ctrl+k":
+						m.fuzzy.move(-1)
+						return m, nil
+					case "down", "ctrl+j":
+						m.fuzzy.move(1)
+						return m, nil
+					case "pgup":
+						m.fuzzy.move(-m.fuzzy.height)
+						return m, nil
+					case "pgdown":
+						m.fuzzy.move(m.fuzzy.height)
+						return m, nil
+				}
 				m.fuzzyInput, cmd = m.fuzzyInput.Update(msg)
 				cmds = append(cmds, cmd)
-				m.performFuzzySearch()
+				m.fuzzy.setQuery(m.fuzzyInput.Value())
 				return m, tea.Batch(cmds...)
 			}
 
@@ -148,9 +310,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// ── Explorer mode shortcuts ──────────────────────────────────────────
+			if m.previewFocus {
+				m.updatePreviewFocus(msg)
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.previewFocus) {
+				m.previewFocus = true
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.diskUsage) {
+				return m, m.openDiskUsage()
+			}
+			if key.Matches(msg, m.keys.diff) {
+				m.openDiff("", "")
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.compare) {
+				return m, m.startCompare(false)
+			}
+			if key.Matches(msg, m.keys.quickView) {
+				m.quickView = !m.quickView
+				m.applyLayout()
+				return m, nil
+			}
 			if key.Matches(msg, m.keys.quit) {
-				m.quitting = true
-				return m, tea.Quit
+				return m, m.promptQuit()
+			}
+			if key.Matches(msg, m.keys.bufferList) {
+				m.openBufferList()
+				return m, nil
 			}
 			if key.Matches(msg, m.keys.refresh) {
 				m.refreshPanel(m.activePanel)
@@ -166,9 +354,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					m.panels[1].fileList.Title = "● Right"
 				}
+				if m.quickView {
+					m.applyLayout()
+				}
 				return m, nil
 			}
 			if key.Matches(msg, m.keys.back) || key.Matches(msg, m.keys.left) {
+				if m.panels[m.activePanel].find != nil {
+					m.closeFind()
+					return m, nil
+				}
 				m.executeCommand("cd ..")
 				return m, nil
 			}
@@ -187,21 +382,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.commandInput.Focused() {
 					cmdStr := m.commandInput.Value()
 					m.commandInput.Reset()
-					m.executeCommand(cmdStr)
-					return m, nil
+					return m, m.executeCommand(cmdStr)
 				}
 				selected, ok := m.panels[m.activePanel].fileList.SelectedItem().(item)
 				if ok {
 					if selected.isDir {
-						m.executeCommand("cd " + selected.title)
+						if err := m.changeDir(m.activePanel, selected.title); err != nil {
+							m.statusMsg = errorStyle.Render(fmt.Sprintf("cd: %v", err))
+						}
 					} else if isArchive(selected.title) {
 						m.mountArchive(selected.title)
 					} else {
-						m.executeCommand("hedit " + selected.title)
+						return m, m.openFile(selected.title)
 					}
 				}
 				return m, nil
 			}
+			if key.Matches(msg, m.keys.view) {
+				if selected, ok := m.panels[m.activePanel].fileList.SelectedItem().(item); ok && !selected.isDir {
+					return m, m.openPager(filepath.Join(m.panels[m.activePanel].currentDir, selected.title))
+				}
+				return m, nil
+			}
+			if key.Matches(msg, m.keys.edit) {
+				if selected, ok := m.panels[m.activePanel].fileList.SelectedItem().(item); ok && !selected.isDir {
+					return m, m.executeCommand("edit " + selected.title)
+				}
+				return m, nil
+			}
 			if key.Matches(msg, m.keys.copy) {
 				m.copyToOtherPanel()
 				return m, nil
@@ -215,11 +423,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			if key.Matches(msg, m.keys.fuzzy) {
-				m.mode = fuzzyMode
-				m.fuzzyInput.Reset()
-				m.fuzzyInput.Focus()
-				m.performFuzzySearch()
-				return m, nil
+				return m, m.openFuzzy()
 			}
 			if key.Matches(msg, m.keys.bulkRename) {
 				m.mode = bulkRenameMode
@@ -294,8 +498,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.commandInput, cmd = m.commandInput.Update(msg)
 		cmds = append(cmds, cmd)
 	} else if m.mode == editorMode {
-		m.editor, cmd = m.editor.Update(msg)
-		cmds = append(cmds, cmd)
+		if b := m.currentBuffer(); b != nil {
+			b.editor, cmd = b.editor.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	} else if m.mode == progressMode {
 		updated, cmd := m.progress.Update(msg)
 		m.progress = updated.(progress.Model)
@@ -305,31 +511,130 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// panelLayout describes how panel idx is laid out on screen. Each panel box
+// holds the file list and, beside or below it, the preview pane; in quick
+// view the active panel's preview fills the inactive panel's box instead.
+type panelLayout struct {
+	boxW     int // lipgloss width of the panel box (padding, no border)
+	innerW   int
+	innerH   int
+	listW    int
+	listH    int
+	previewW int
+	previewH int
+	previewX int // screen cell where the preview content starts
+	previewY int
+	side     bool
+}
+
+func (m *Model) panelLayout(idx int) panelLayout {
+	w, h := m.termW, m.termH
+	if w < 40 {
+		w = 80
+	}
+	if h == 0 {
+		h = 24
+	}
+	boxW := w/2 - 3
+	l := panelLayout{boxW: boxW, innerW: boxW - 2, innerH: h - 9}
+	if l.innerH < 5 {
+		l.innerH = 5
+	}
+	// title + path bar + border on top; border + padding on the left
+	top := 3
+	left := func(i int) int { return i*(boxW+2) + 2 }
+	switch {
+		case m.quickView && idx == m.activePanel:
+			l.listW, l.listH = l.innerW, l.innerH
+			l.previewW, l.previewH = l.innerW, l.innerH-1
+			l.previewX, l.previewY = left(1-idx), top+1
+		case m.quickView:
+			l.listW, l.listH = l.innerW, l.innerH
+		case l.innerW >= 80:
+			l.side = true
+			l.listW, l.listH = l.innerW/2, l.innerH
+			l.previewW, l.previewH = l.innerW-l.listW-1, l.innerH-1
+			l.previewX, l.previewY = left(idx)+l.listW+1, top+1
+		default:
+			l.listW, l.listH = l.innerW, l.innerH/2
+			l.previewW, l.previewH = l.innerW, l.innerH-l.listH-1
+			l.previewX, l.previewY = left(idx), top+l.listH+1
+	}
+	return l
+}
+
 func (m *Model) applyLayout() {
 	w, h := m.termW, m.termH
 	if w == 0 || h == 0 {
 		return
 	}
-	topH := 3 // title + path bar
-	botH := 3 // status + fbar
-	contentH := h - topH - botH
-	if contentH < 5 {
-		contentH = 5
-	}
-	halfW := (w / 2) - 4
-
 	for i := range m.panels {
-		m.panels[i].fileList.SetSize(halfW, contentH-2)
-		m.panels[i].preview.Width = halfW
-		m.panels[i].preview.Height = contentH / 2
+		l := m.panelLayout(i)
+		// one row below the list is kept for the selection footer
+		m.panels[i].fileList.SetSize(l.listW, l.listH-1)
+		m.panels[i].preview.Width = l.previewW
+		m.panels[i].preview.Height = l.previewH
+		m.updatePreview(i)
+	}
+	edW, edH := m.editorSize()
+	for i := range m.buffers {
+		m.buffers[i].editor.SetWidth(edW)
+		m.buffers[i].editor.SetHeight(edH)
+	}
+	m.hex.width, m.hex.height = edW, edH
+	m.du.width, m.du.height = edW, edH
+	if m.du.cur != nil {
+		m.du.clamp()
+	}
+	m.fuzzy.height = max(m.termH-8, 3)
+	m.grep.width, m.grep.height = edW, edH
+	m.grep.scroll()
+	if m.replace != nil {
+		m.replace.width, m.replace.height = edW, edH
+		m.replace.scroll()
+	}
+	m.diff.width, m.diff.height = edW, edH
+	m.diff.clampTop()
+	if m.sync != nil {
+		m.sync.width, m.sync.height = edW, edH
+		m.sync.clamp()
+	}
+	if m.mode == pagerMode {
+		m.pager.width, m.pager.height = edW, edH
+		m.pager.refresh()
 	}
-	m.editor.SetWidth(w - 4)
-	m.editor.SetHeight(contentH)
 	m.commandInput.Width = w - 6
 	m.progress.Width = w - 4
 	m.fuzzyInput.Width = w - 4
 }
 
+// updatePreviewFocus scrolls the active panel's preview while it has focus.
+func (m *Model) updatePreviewFocus(msg tea.KeyMsg) {
+	vp := &m.panels[m.activePanel].preview
+	switch {
+		case key.Matches(msg, m.keys.cancel), key.Matches(msg, m.keys.previewFocus), key.Matches(msg, m.keys.tab), msg.String() == "q":
+			m.previewFocus = false
+		case key.Matches(msg, m.keys.down):
+			vp.LineDown(1)
+		case key.Matches(msg, m.keys.up):
+			vp.LineUp(1)
+	}
+	switch msg.String() {
+		case "pgdown", " ", "f":
+			vp.ViewDown()
+		case "pgup", "b":
+			vp.ViewUp()
+		case "d":
+			vp.HalfViewDown()
+		case "u":
+			vp.HalfViewUp()
+		case "g", "home":
+			vp.GotoTop()
+		case "G", "end":
+			vp.GotoBottom()
+	}
+}
+
 func (m *Model) syncSelectionToList(idx int) {
 	p := &m.panels[idx]
 	items := p.fileList.Items()
@@ -347,21 +652,42 @@ func (m *Model) showHelp() {
 		"ngt keybindings:",
 		"  Tab       – switch panel",
 		"  Enter/l   – open dir/file/archive",
-		"  Backspace – cd ..",
+		"  Backspace – cd .. (leaves find results)",
 		"  Space     – select/deselect",
+		"  F3        – view file in pager (large files, follow mode)",
+		"  F4        – edit with $EDITOR",
 		"  F5        – copy to other panel",
 		"  F6        – move to other panel",
 		"  F8        – delete (with confirmation)",
-		"  Ctrl+P    – fuzzy search",
+		"  Ctrl+P    – fuzzy search (Enter go to, Alt+Enter edit, Space select)",
 		"  Ctrl+R    – bulk rename (regex)",
 		"  Ctrl+S    – cycle sort mode",
 		"  Ctrl+D    – podman container browser",
 		"  Ctrl+U    – duplicate file",
 		"  Ctrl+O    – open sub-shell",
+		"  Ctrl+L    – editor buffer list",
+		"  Ctrl+←/→  – previous/next buffer (editor)",
+		"  Ctrl+X    – close buffer (editor)",
+		"  Alt+E/R   – cycle encoding / toggle CRLF (editor)",
+		"Pager: / ? search, n/N next/prev, : line or NN%, g/G top/end, F follow, q quit",
+		"Openers: " + openersPath() + " (ext/mime/glob → command)",
+		"Image preview: " + m.imageProto.String() + " (set NGT_IMAGE_PROTOCOL=kitty|sixel|halfblock|none)",
+		"Live refresh: inotify for local panels, sftp/podman polled every " + pollIntervalString(m.pollInterval) + " (set NGT_POLL_INTERVAL=10s|off)",
+		"Hex editor: Tab hex/ASCII, Ctrl+G goto offset, Ctrl+F find bytes, Ctrl+N next, Ctrl+S save",
+		"  Alt+P     – focus preview (j/k, PgUp/PgDn, g/G scroll; Esc back)",
+		"  Ctrl+Q    – quick view of the cursor item in the other panel",
+		"  Ctrl+G    – disk usage (Enter/l into, h/Bs up, d delete, r rescan)",
+		"  Alt+D     – diff the cursor files of both panels (n/N hunks, Tab unified, >/< copy hunk, Ctrl+S save)",
+		"  Alt+C     – compare panel directories (compare sum: by checksum, compare off: clear)",
+		"  find [DIR] [!] [-name|-iname|-path GLOB] [-type f|d|l] [-size ±N[kMG]] [-mtime ±N[smhdw]] [-newer DATE] [-perm [-/]MODE] [-user|-group ID] [-maxdepth N] – list matches in the panel (Bs returns)",
+		"  index [rebuild] – show or rebuild the search index of the roots in " + indexConfigPath(),
+		"  grep [-F] [-i] [-g GLOB|!GLOB] [-C N] PATTERN – search file contents (Enter opens at the line)",
+		"  replace [grep flags] PATTERN REPL (or /PATTERN/REPL/) – replace in the selection or tree; replace undo",
+		"  sync [two-way] [delete] – mirror the active panel to the other (dry run first)",
 		"  Ctrl+Z    – suspend",
 		"  r         – refresh panel",
 		"  q/Ctrl+C  – quit",
-		"Commands: cd, cp, mv, rm, mkdir, touch, hedit, sftp, podman, podmanls",
+		"Commands: cd, cp, mv, rm, mkdir, touch, hedit, hexedit, less, open, edit, encoding, eol, du, diff, compare, sync, find, grep, index, replace, sftp, podman, podmanls",
 	}
 	m.statusMsg = successStyle.Render(strings.Join(help, "\n"))
 }
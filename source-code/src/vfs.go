@@ -30,6 +30,8 @@ type vfsHandler interface {
 	Rename(src, dst string) error
 	MkdirAll(path string, perm fs.FileMode) error
 	Create(path string) (io.WriteCloser, error)
+	Chmod(path string, mode fs.FileMode) error
+	Chtimes(path string, atime, mtime time.Time) error
 	VFSName() string
 }
 
@@ -50,6 +52,10 @@ func (l localVFS) MkdirAll(path string, perm fs.FileMode) error {
 	return os.MkdirAll(path, perm)
 }
 func (l localVFS) Create(path string) (io.WriteCloser, error) { return os.Create(path) }
+func (l localVFS) Chmod(path string, mode fs.FileMode) error  { return os.Chmod(path, mode) }
+func (l localVFS) Chtimes(path string, atime, mtime time.Time) error {
+	return os.Chtimes(path, atime, mtime)
+}
 func (l localVFS) VFSName() string                            { return "local" }
 
 // ─────────────────────────────────────────────
@@ -99,6 +105,10 @@ func (s *sftpVFS) MkdirAll(path string, perm fs.FileMode) error {
 	return s.client.MkdirAll(path)
 }
 func (s *sftpVFS) Create(path string) (io.WriteCloser, error) { return s.client.Create(path) }
+func (s *sftpVFS) Chmod(path string, mode fs.FileMode) error  { return s.client.Chmod(path, mode) }
+func (s *sftpVFS) Chtimes(path string, atime, mtime time.Time) error {
+	return s.client.Chtimes(path, atime, mtime)
+}
 func (s *sftpVFS) VFSName() string                            { return "sftp://" + s.host }
 
 type sftpDirEntry struct{ info fs.FileInfo }
@@ -217,11 +227,17 @@ type podmanFileInfo struct {
 	name  string
 	isDir bool
 	size  int64
+	perm  fs.FileMode // 0 when the listing did not say
 }
 
-func (i *podmanFileInfo) Name() string       { return i.name }
-func (i *podmanFileInfo) Size() int64        { return i.size }
-func (i *podmanFileInfo) Mode() fs.FileMode  { return 0644 }
+func (i *podmanFileInfo) Name() string { return i.name }
+func (i *podmanFileInfo) Size() int64  { return i.size }
+func (i *podmanFileInfo) Mode() fs.FileMode {
+	if i.perm != 0 {
+		return i.perm
+	}
+	return 0644
+}
 func (i *podmanFileInfo) ModTime() time.Time { return time.Now() }
 func (i *podmanFileInfo) IsDir() bool        { return i.isDir }
 func (i *podmanFileInfo) Sys() any           { return nil }
@@ -259,7 +275,7 @@ func (f *podmanFile) Stat() (fs.FileInfo, error) {
 }
 
 func (p *podmanVFS) Stat(file string) (fs.FileInfo, error) {
-	out, err := p.podmanExec("stat", "-c", "%n %s %F", file)
+	out, err := p.podmanExec("stat", "-c", "%s %a %F", file)
 	if err != nil {
 		return nil, fs.ErrNotExist
 	}
@@ -268,9 +284,11 @@ func (p *podmanVFS) Stat(file string) (fs.FileInfo, error) {
 		return nil, fmt.Errorf("unexpected stat output")
 	}
 	var size int64
-	fmt.Sscanf(fields[1], "%d", &size)
+	var perm uint32
+	fmt.Sscanf(fields[0], "%d", &size)
+	fmt.Sscanf(fields[1], "%o", &perm)
 	isDir := strings.Contains(fields[2], "directory")
-	return &podmanFileInfo{name: filepath.Base(file), isDir: isDir, size: size}, nil
+	return &podmanFileInfo{name: filepath.Base(file), isDir: isDir, size: size, perm: fs.FileMode(perm) & fs.ModePerm}, nil
 }
 
 func (p *podmanVFS) Chdir(dir string) error {
@@ -303,6 +321,16 @@ func (p *podmanVFS) Create(path string) (io.WriteCloser, error) {
 	return &podmanWriter{containerID: p.containerID, path: path}, nil
 }
 
+func (p *podmanVFS) Chmod(path string, mode fs.FileMode) error {
+	_, err := exec.Command("podman", "exec", p.containerID, "chmod", fmt.Sprintf("%o", mode.Perm()), path).Output()
+	return err
+}
+
+func (p *podmanVFS) Chtimes(path string, atime, mtime time.Time) error {
+	_, err := exec.Command("podman", "exec", p.containerID, "touch", "-c", "-m", "-d", fmt.Sprintf("@%d", mtime.Unix()), path).Output()
+	return err
+}
+
 type podmanWriter struct {
 	containerID string
 	path        string
@@ -446,6 +474,8 @@ func (t *tarVFS) MkdirAll(p string, _ fs.FileMode) error { return fmt.Errorf("TA
 func (t *tarVFS) Create(path string) (io.WriteCloser, error) {
 	return nil, fmt.Errorf("TAR is read-only")
 }
+func (t *tarVFS) Chmod(p string, _ fs.FileMode) error { return fmt.Errorf("TAR is read-only") }
+func (t *tarVFS) Chtimes(p string, _, _ time.Time) error { return fmt.Errorf("TAR is read-only") }
 func (t *tarVFS) VFSName() string { return "tar://" + filepath.Base(t.filename) }
 
 type tarDirEntry struct {
@@ -572,8 +602,13 @@ func (z *zipVFS) MkdirAll(p string, _ fs.FileMode) error { return fmt.Errorf("ZI
 func (z *zipVFS) Create(path string) (io.WriteCloser, error) {
 	return nil, fmt.Errorf("ZIP is read-only")
 }
+func (z *zipVFS) Chmod(p string, _ fs.FileMode) error { return fmt.Errorf("ZIP is read-only") }
+func (z *zipVFS) Chtimes(p string, _, _ time.Time) error { return fmt.Errorf("ZIP is read-only") }
 func (z *zipVFS) VFSName() string { return "zip://" + filepath.Base(z.filename) }
 
+// Close releases the underlying archive file.
+func (z *zipVFS) Close() error { return z.file.Close() }
+
 type zipDirEntry struct {
 	f     *zip.File
 	isDir bool
@@ -38,23 +38,144 @@ func (m Model) View() string {
 	}
 
 	// ── Editor mode ───────────────────────────────────────────────────────────
-	if m.mode == editorMode {
+	if m.mode == editorMode && len(m.buffers) > 0 {
+		b := m.buffers[m.activeBuffer]
 		editorBar := titleBarStyle.Width(w).Render(
-			"  ✎ Editing: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.editorFile) +
-			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Ctrl+S save  •  Esc cancel"),
+			"  ✎ Editing: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(b.file) +
+			"  " + sortTagStyle.Render(b.format.String()) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Ctrl+S save  •  Ctrl+X close  •  Ctrl+L buffers  •  Esc back"),
 		)
-		editorView := editorStyle.Width(w - 2).Render(m.editor.View())
+		tabs := m.renderBufferTabs(w)
+		editorView := editorStyle.Width(w - 2).Render(b.editor.View())
 		status := statusBarStyle.Width(w).Render(m.statusMsg)
-		return lipgloss.JoinVertical(lipgloss.Left, titleBar, editorBar, editorView, status, fBar)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, editorBar, tabs, editorView, status, fBar)
+	}
+
+	// ── Hex editor mode ───────────────────────────────────────────────────────
+	if m.mode == hexMode {
+		hexBar := titleBarStyle.Width(w).Render(
+			"  ⬡ Hex: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.hex.file) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Tab hex/ASCII  •  ^G goto  •  ^F find  •  ^N next  •  ^S save  •  Esc back"),
+		)
+		hexView := editorStyle.Width(w - 2).Render(m.hex.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  " + m.hex.statusLine())
+		if m.hex.prompt != hexPromptNone {
+			label := "Goto: "
+			if m.hex.prompt == hexPromptSearch {
+				label = "Find: "
+			}
+			info = inputStyle.Width(w - 2).Render(label + m.hex.input.View())
+		}
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, hexBar, hexView, info, status, fBar)
+	}
+
+	// ── Pager mode ────────────────────────────────────────────────────────────
+	if m.mode == pagerMode {
+		pagerBar := titleBarStyle.Width(w).Render(
+			"  ☰ View: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.pager.file) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   / search  •  : goto  •  F follow  •  q back"),
+		)
+		body := editorStyle.Width(w - 2).Render(m.pager.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  " + m.pager.statusLine())
+		if m.pager.prompt != pagerPromptNone {
+			label := map[pagerPrompt]string{pagerPromptGoto: "Goto: ", pagerPromptSearch: "/", pagerPromptSearchBack: "?"}[m.pager.prompt]
+			info = inputStyle.Width(w - 2).Render(label + m.pager.input.View())
+		}
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, pagerBar, body, info, status, fBar)
+	}
+
+	// ── Disk usage mode ───────────────────────────────────────────────────────
+	if m.mode == duMode {
+		duBar := titleBarStyle.Width(w).Render(
+			"  ▤ Disk usage: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.du.title()) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Enter open  •  Bs up  •  d delete  •  r rescan  •  q back"),
+		)
+		body := editorStyle.Width(w - 2).Height(m.du.height).Render(m.du.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  " + m.du.statusLine())
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, duBar, body, info, status, fBar)
+	}
+
+	// ── Diff mode ─────────────────────────────────────────────────────────────
+	if m.mode == diffMode {
+		diffBar := titleBarStyle.Width(w).MaxHeight(1).Render(
+			"  ⇄ Diff: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.diff.title()) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   n/N hunk  •  Tab unified  •  >/< copy hunk  •  ^S save  •  q back"),
+		)
+		body := editorStyle.Width(w - 2).Height(m.diff.height).Render(m.diff.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  " + m.diff.statusLine())
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, diffBar, body, info, status, fBar)
+	}
+
+	// ── Content search results ────────────────────────────────────────────────
+	if m.mode == grepMode {
+		grepBar := titleBarStyle.Width(w).MaxHeight(1).Render(
+			"  ⌕ grep: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.grep.title()) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Enter open at line  •  q back"),
+		)
+		body := editorStyle.Width(w - 2).Height(m.grep.height).Render(m.grep.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  " + m.grep.statusLine())
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, grepBar, body, info, status, fBar)
+	}
+
+	// ── Replace preview ───────────────────────────────────────────────────────
+	if m.mode == replaceMode && m.replace != nil {
+		replaceBar := titleBarStyle.Width(w).MaxHeight(1).Render(
+			"  ⇆ Replace: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.replace.title()) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Space toggle  •  a file  •  A all  •  Enter/y apply  •  q cancel"),
+		)
+		body := editorStyle.Width(w - 2).Height(m.replace.height).Render(m.replace.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  " + m.replace.statusLine())
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, replaceBar, body, info, status, fBar)
+	}
+
+	// ── Sync plan mode ────────────────────────────────────────────────────────
+	if m.mode == syncMode && m.sync != nil {
+		syncBar := titleBarStyle.Width(w).MaxHeight(1).Render(
+			"  ⟳ Sync: " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render(m.sync.title()) +
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("   Enter/y apply  •  q cancel"),
+		)
+		body := editorStyle.Width(w - 2).Height(m.sync.height).Render(m.sync.view())
+		info := lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).MaxWidth(w).Render("  " + m.sync.statusLine())
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, syncBar, body, info, status, fBar)
+	}
+
+	// ── Buffer list mode ──────────────────────────────────────────────────────
+	if m.mode == bufferListMode {
+		header := titleBarStyle.Width(w).Render("  ☰ Open Buffers " +
+		lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render("  Enter: switch  •  Ctrl+X: close  •  Esc: back"))
+		var rows []string
+		for i := range m.buffers {
+			b := &m.buffers[i]
+			prefix := "  "
+			if i == m.bufferCursor {
+				prefix = lipgloss.NewStyle().Foreground(lipgloss.Color(colorAccent)).Render("▶ ")
+			}
+			name := fileStyle.Render(b.name())
+			if b.dirty {
+				name = gitModifiedStyle.Render(b.name())
+			}
+			rows = append(rows, prefix+name+"  "+
+			lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render(b.vfs.VFSName()+" "+b.file))
+		}
+		box := inactivePanelBorder.Width(w - 2).Render(strings.Join(rows, "\n"))
+		status := statusBarStyle.Width(w).Render(m.statusMsg)
+		return lipgloss.JoinVertical(lipgloss.Left, titleBar, header, box, status, fBar)
 	}
 
 	// ── Fuzzy mode ────────────────────────────────────────────────────────────
 	if m.mode == fuzzyMode {
 		searchBar := inputStyle.Width(w - 2).Render("  🔍 " + m.fuzzyInput.View())
-		listView := inactivePanelBorder.Width(w - 2).Render(m.panels[m.activePanel].fileList.View())
+		listView := inactivePanelBorder.Width(w - 2).Height(m.fuzzy.height).Render(m.fuzzy.view(w-4, m.panels[m.activePanel].selectedFiles))
 		status := statusBarStyle.Width(w).Render(
 			m.statusMsg + lipgloss.NewStyle().Foreground(lipgloss.Color(colorMuted)).Render(
-				fmt.Sprintf("  %d results  •  Enter: navigate  •  Esc: cancel", len(m.fuzzyResults)),
+				"  " + m.fuzzy.statusLine(m.panels[m.activePanel].selectedFiles) + "  •  Enter: go to  •  Alt+Enter: edit  •  Space: select  •  Esc: cancel",
 			),
 		)
 		return lipgloss.JoinVertical(lipgloss.Left, titleBar, searchBar, listView, status, fBar)
@@ -104,7 +225,7 @@ func (m Model) View() string {
 	}
 
 	// ── Explorer mode (main) ──────────────────────────────────────────────────
-	halfW := (w / 2) - 3
+	halfW := m.panelLayout(0).boxW
 
 	// Path bars
 	leftPath := m.renderPathBar(0, halfW)
@@ -138,19 +259,23 @@ func (m *Model) renderPathBar(idx, w int) string {
 	p := &m.panels[idx]
 	vfsTag := vfsTagStyle.Render(p.vfs.VFSName())
 	dir := pathStyle.Render(truncatePath(p.currentDir, w-20))
+	if p.find != nil {
+		query := warnStyle.Render("find " + p.find.query)
+		dir = pathStyle.Render(truncatePath(p.currentDir, max(w-20-lipgloss.Width(query), 10))) + " " + query
+	}
 	branch := ""
 	if p.gitBranch != "" {
 		branch = " " + branchStyle.Render(" "+p.gitBranch+" ")
 	}
 	sort := sortTagStyle.Render(p.sortMode.String())
-	return lipgloss.NewStyle().Width(w + 2).Render(
+	return lipgloss.NewStyle().Width(w + 2).MaxWidth(w + 2).MaxHeight(1).Render(
 		lipgloss.JoinHorizontal(lipgloss.Top, vfsTag, " ", dir, branch, " ", sort),
 	)
 }
 
 func (m *Model) renderPanel(idx, w int) string {
 	p := &m.panels[idx]
-	listView := p.fileList.View()
+	l := m.panelLayout(idx)
 	sel := 0
 	for _, v := range p.selectedFiles {
 		if v {
@@ -159,15 +284,84 @@ func (m *Model) renderPanel(idx, w int) string {
 	}
 	footer := ""
 	if sel > 0 {
-		footer = "\n" + warnStyle.Render(fmt.Sprintf("  %d selected", sel))
+		footer = warnStyle.Render(fmt.Sprintf("  %d selected", sel))
+	}
+	list := lipgloss.NewStyle().Width(l.listW).MaxWidth(l.listW).Height(l.listH).MaxHeight(l.listH).
+	Render(p.fileList.View() + "\n" + footer)
+
+	var content string
+	switch {
+		case m.quickView && idx != m.activePanel:
+			a := &m.panels[m.activePanel]
+			content = m.previewHeader(m.activePanel, l.innerW) + "\n" + a.preview.View()
+		case m.quickView || l.previewH <= 0:
+			content = list
+		case l.side:
+			sep := hexZeroStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", l.innerH), "\n"))
+			pv := m.previewHeader(idx, l.previewW) + "\n" + p.preview.View()
+			content = lipgloss.JoinHorizontal(lipgloss.Top, list, sep, pv)
+		default:
+			content = list + "\n" + m.previewHeader(idx, l.innerW) + "\n" + p.preview.View()
 	}
-	content := listView + footer
 	if idx == m.activePanel {
 		return activePanelBorder.Width(w).Render(content)
 	}
 	return inactivePanelBorder.Width(w).Render(content)
 }
 
+// previewHeader is the rule above a preview pane: the item name, the scroll
+// position and whether the pane has focus.
+func (m *Model) previewHeader(idx, w int) string {
+	p := &m.panels[idx]
+	title := "Preview"
+	if m.quickView {
+		title = "Quick view"
+	}
+	if it, ok := p.fileList.SelectedItem().(item); ok {
+		title += ": " + it.title
+	}
+	style := metaKeyStyle
+	if m.previewFocus && idx == m.activePanel {
+		style = panelTitleStyle
+		title += " · Esc back"
+	}
+	pos := ""
+	if !p.preview.AtTop() || !p.preview.AtBottom() {
+		pos = fmt.Sprintf(" %d%% ", int(p.preview.ScrollPercent()*100))
+	}
+	title = truncateRunes(title, w-lipgloss.Width(pos)-4)
+	fill := w - lipgloss.Width(title) - lipgloss.Width(pos) - 3
+	if fill < 0 {
+		fill = 0
+	}
+	return hexZeroStyle.Render("─ ") + style.Render(title) + hexZeroStyle.Render(" "+strings.Repeat("─", fill)) + metaKeyStyle.Render(pos)
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if n < 1 {
+		return ""
+	}
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// renderBufferTabs draws one tab per open buffer, highlighting the active one.
+func (m *Model) renderBufferTabs(w int) string {
+	var tabs []string
+	for i := range m.buffers {
+		b := &m.buffers[i]
+		if i == m.activeBuffer {
+			tabs = append(tabs, bufferTabActiveStyle.Render(b.name()))
+		} else {
+			tabs = append(tabs, bufferTabStyle.Render(b.name()))
+		}
+	}
+	return lipgloss.NewStyle().Width(w).MaxWidth(w).Render(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
+}
+
 func truncatePath(path string, maxLen int) string {
 	if len(path) <= maxLen {
 		return path
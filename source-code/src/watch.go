@@ -0,0 +1,245 @@
+package src
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ─── Live refresh ─────────────────────────────────────────────────────────────
+
+const (
+	watchDebounce       = 250 * time.Millisecond // events are gathered this long
+	defaultPollInterval = 5 * time.Second
+)
+
+// dirWatch follows one panel directory: inotify for local panels, a
+// periodic listing for sftp and podman. Archives and disabled polling get
+// an inactive watch (nil changed) so they are not retried on every update.
+type dirWatch struct {
+	vfs     vfsHandler
+	dir     string
+	changed chan struct{}
+	cancel  context.CancelFunc
+}
+
+// dirChangedMsg reports that a watched directory changed on disk.
+type dirChangedMsg struct {
+	idx   int
+	watch *dirWatch
+}
+
+// pollIntervalFromEnv reads NGT_POLL_INTERVAL (a Go duration, or 0/off to
+// disable polling of remote panels).
+func pollIntervalFromEnv() time.Duration {
+	v := strings.ToLower(os.Getenv("NGT_POLL_INTERVAL"))
+	switch v {
+		case "":
+			return defaultPollInterval
+		case "0", "off":
+			return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return defaultPollInterval
+	}
+	return d
+}
+
+func pollIntervalString(d time.Duration) string {
+	if d <= 0 {
+		return "never"
+	}
+	return d.String()
+}
+
+func startDirWatch(vfs vfsHandler, dir string, poll time.Duration) *dirWatch {
+	w := &dirWatch{vfs: vfs, dir: dir}
+	switch vfs.(type) {
+		case localVFS:
+			fw, err := fsnotify.NewWatcher()
+			if err != nil {
+				return w
+			}
+			if err := fw.Add(dir); err != nil {
+				fw.Close()
+				return w
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			w.changed, w.cancel = make(chan struct{}, 1), cancel
+			go w.notify(ctx, fw)
+		case *sftpVFS, *podmanVFS:
+			if poll <= 0 {
+				return w
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			w.changed, w.cancel = make(chan struct{}, 1), cancel
+			go w.poll(ctx, poll)
+	}
+	return w
+}
+
+func (w *dirWatch) stop() {
+	if w != nil && w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// signal marks the directory changed; one pending signal is enough.
+func (w *dirWatch) signal() {
+	select {
+		case w.changed <- struct{}{}:
+		default:
+	}
+}
+
+// notify turns inotify events into signals, at most one per debounce
+// window so a busy directory can't flood the UI.
+func (w *dirWatch) notify(ctx context.Context, fw *fsnotify.Watcher) {
+	defer close(w.changed)
+	defer fw.Close()
+	var window <-chan time.Time
+	for {
+		select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-fw.Events:
+				if !ok {
+					return
+				}
+				if window == nil {
+					window = time.After(watchDebounce)
+				}
+			case _, ok := <-fw.Errors:
+				// An overflow loses events; a refresh catches up.
+				if !ok {
+					return
+				}
+				if window == nil {
+					window = time.After(watchDebounce)
+				}
+			case <-window:
+				window = nil
+				w.signal()
+		}
+	}
+}
+
+// poll lists the directory every interval and signals when the listing
+// differs from the last one.
+func (w *dirWatch) poll(ctx context.Context, every time.Duration) {
+	defer close(w.changed)
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+	prev := dirSignature(w.vfs, w.dir)
+	for {
+		select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+				if sig := dirSignature(w.vfs, w.dir); sig != prev {
+					prev = sig
+					w.signal()
+				}
+		}
+	}
+}
+
+// dirSignature hashes a directory listing: names, types, sizes and times.
+func dirSignature(vfs vfsHandler, dir string) [32]byte {
+	h := sha256.New()
+	entries, err := vfs.ReadDir(dir)
+	if err != nil {
+		fmt.Fprint(h, err)
+	}
+	_, podman := vfs.(*podmanVFS) // podman listings carry no mtime
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%v\x00%d", e.Name(), e.IsDir(), info.Size())
+		if !podman {
+			fmt.Fprintf(h, "\x00%d", info.ModTime().UnixNano())
+		}
+		h.Write([]byte{'\n'})
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// wait delivers the next change of the watch to panel idx; a stopped
+// watch closes changed and delivers nothing.
+func (w *dirWatch) wait(idx int) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-w.changed; !ok {
+			return nil
+		}
+		return dirChangedMsg{idx: idx, watch: w}
+	}
+}
+
+// watchCmds restarts the watch of every panel whose directory or backend
+// changed and returns the commands waiting on the new watches. It also
+// applies a change held off while a filter was typed.
+func (m *Model) watchCmds() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.panels {
+		p := &m.panels[i]
+		if p.watchPending && p.fileList.FilterState() != list.Filtering {
+			m.liveRefresh(i)
+		}
+		if w := p.watch; w != nil && w.dir == p.currentDir && w.vfs == p.vfs {
+			continue
+		}
+		p.watch.stop()
+		p.watch = startDirWatch(p.vfs, p.currentDir, m.pollInterval)
+		if p.watch.changed != nil {
+			cmds = append(cmds, p.watch.wait(i))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleDirChanged refreshes the panel unless its watch has been replaced,
+// then waits for the next change.
+func (m *Model) handleDirChanged(msg dirChangedMsg) tea.Cmd {
+	if m.panels[msg.idx].watch != msg.watch {
+		return nil
+	}
+	m.liveRefresh(msg.idx)
+	return msg.watch.wait(msg.idx)
+}
+
+// liveRefresh reloads panel idx after an outside change (refreshPanel keeps
+// the cursor) and drops vanished entries from the selection. While the user
+// is typing a list filter it only marks the panel pending.
+func (m *Model) liveRefresh(idx int) {
+	p := &m.panels[idx]
+	if p.fileList.FilterState() == list.Filtering {
+		p.watchPending = true
+		return
+	}
+	p.watchPending = false
+	m.refreshPanel(idx)
+	listed := make(map[string]bool)
+	for _, it := range p.fileList.Items() {
+		listed[filepath.Join(p.currentDir, it.(item).title)] = true
+	}
+	for f := range p.selectedFiles {
+		inView := filepath.Dir(f) == p.currentDir ||
+		p.find != nil && strings.HasPrefix(f, p.currentDir+string(filepath.Separator))
+		if inView && !listed[f] {
+			delete(p.selectedFiles, f)
+		}
+	}
+}